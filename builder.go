@@ -0,0 +1,178 @@
+package gormx
+
+import (
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Builder 是一个以链式调用组合查询范围的构建器。
+// 每个方法追加一个 Scope，参数未设置时自动忽略该条件，适合根据可选的查询参数组装过滤条件。
+// 未设置指 nil、nil 指针、空字符串以及空切片、映射和数组；0、false 等其他零值是有效的过滤值，不会被忽略。
+// 非 nil 的指针会取其指向的值再判断，因此可以直接传入 *int、*bool 等可选参数。
+// 需要按空字符串过滤时使用 EqAlways。
+//
+//	db = gormx.NewBuilder().
+//		Eq("status", req.Status).
+//		Like("name", req.Keyword).
+//		Between("created_at", req.From, req.To).
+//		Order(req.OrderBy, "-id").
+//		Page(req.Page, req.Size).
+//		Apply(db.Model(&User{}))
+type Builder struct {
+	scopes []Scope
+}
+
+// NewBuilder 创建一个空的 Builder。
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Add 追加任意的查询范围。
+func (b *Builder) Add(scopes ...Scope) *Builder {
+	b.scopes = append(b.scopes, scopes...)
+	return b
+}
+
+// Eq 追加 column = value 条件，value 未设置时忽略，规则见 Builder。
+func (b *Builder) Eq(col string, value any) *Builder {
+	value, ok := filterValue(value)
+	if !ok {
+		return b
+	}
+	return b.EqAlways(col, value)
+}
+
+// EqAlways 追加 column = value 条件，除 nil 和 nil 指针外不忽略任何值（包括空字符串）。
+// value 为 nil 或 nil 指针时生成 column IS NULL。
+func (b *Builder) EqAlways(col string, value any) *Builder {
+	value, _ = derefValue(value)
+	if value == nil {
+		return b.Add(IsNull(col))
+	}
+	return b.Add(func(db *gorm.DB) *gorm.DB {
+		return db.Where("? = ?", column(col), value)
+	})
+}
+
+// Like 追加模糊匹配条件，q 为空时忽略。
+func (b *Builder) Like(col, q string) *Builder {
+	if q == "" {
+		return b
+	}
+	return b.Add(Like(col, q))
+}
+
+// In 追加 column IN (values) 条件，values 应为切片，为空时忽略，不是切片时等同于 Eq。
+func (b *Builder) In(col string, values any) *Builder {
+	values, ok := filterValue(values)
+	if !ok {
+		return b
+	}
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return b.Eq(col, values)
+	}
+	vs := make([]any, rv.Len())
+	for i := range vs {
+		vs[i] = rv.Index(i).Interface()
+	}
+	return b.Add(In(col, vs))
+}
+
+// Between 追加范围条件。lo 和 hi 都未设置时忽略，只有一端未设置时退化为单边的 >= 或 <= 条件。
+// 与 Between 查询范围一样，lo 大于 hi 时交换两者；能比较大小的是同类型的数字、字符串和 time.Time。
+func (b *Builder) Between(col string, lo, hi any) *Builder {
+	lo, loOK := filterValue(lo)
+	hi, hiOK := filterValue(hi)
+	switch {
+	case !loOK && !hiOK:
+		return b
+	case !hiOK:
+		return b.Add(Gte(col, lo))
+	case !loOK:
+		return b.Add(Lte(col, hi))
+	}
+	if boundGreater(lo, hi) {
+		lo, hi = hi, lo
+	}
+	return b.Add(func(db *gorm.DB) *gorm.DB { return db.Where("? BETWEEN ? AND ?", column(col), lo, hi) })
+}
+
+// Order 追加排序，规则同 OrderBy。
+func (b *Builder) Order(orderBy, def string) *Builder {
+	if orderBy == "" && def == "" {
+		return b
+	}
+	return b.Add(OrderBy(orderBy, def))
+}
+
+// Page 追加分页，规则同 Paging。page 和 size 都小于等于 0 时不分页。
+func (b *Builder) Page(page, size int) *Builder {
+	if page <= 0 && size <= 0 {
+		return b
+	}
+	return b.Add(Paging[int, int, int](page, size))
+}
+
+// Build 返回已追加的全部查询范围。
+func (b *Builder) Build() []Scope {
+	return append([]Scope(nil), b.scopes...)
+}
+
+// Apply 将已追加的全部查询范围应用到 db 上。
+func (b *Builder) Apply(db *gorm.DB) *gorm.DB {
+	return db.Scopes(funcs(b.scopes)...)
+}
+
+// derefValue 取出 v 中指针指向的值，v 为 nil 或 nil 指针时返回 nil 和 false。
+func derefValue(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, false
+	}
+	return rv.Interface(), true
+}
+
+// filterValue 返回作为过滤条件使用的值，v 未设置时返回 false，规则见 Builder。
+func filterValue(v any) (any, bool) {
+	v, ok := derefValue(v)
+	if !ok {
+		return nil, false
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v, rv.Len() > 0
+	}
+	return v, true
+}
+
+// boundGreater 判断 lo 是否大于 hi，两者类型不同或无法比较时返回 false。
+func boundGreater(lo, hi any) bool {
+	if a, ok := lo.(time.Time); ok {
+		b, ok := hi.(time.Time)
+		return ok && a.After(b)
+	}
+	a, b := reflect.ValueOf(lo), reflect.ValueOf(hi)
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch {
+	case a.CanInt():
+		return a.Int() > b.Int()
+	case a.CanUint():
+		return a.Uint() > b.Uint()
+	case a.CanFloat():
+		return a.Float() > b.Float()
+	case a.Kind() == reflect.String:
+		return a.String() > b.String()
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package gormx
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestBuilder(t *testing.T) {
+	var ids []int
+
+	sql := toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return NewBuilder().
+			Eq("sort", 3).
+			Eq("name", "").
+			Like("note", "abc").
+			In("id", ids).
+			In("zzs.id", []int{1, 2}).
+			Between("updated_at", 10, nil).
+			Order("-id", "").
+			Page(2, 10).
+			Apply(tx.Model(&ZZ{})).
+			Find(&[]ZZ{})
+	})
+
+	want := "SELECT * FROM `zzs` WHERE `zzs`.`sort` = 3 AND `zzs`.`note` LIKE \"%abc%\" AND `zzs`.`id` IN (1,2) " +
+		"AND `zzs`.`updated_at` >= 10 ORDER BY `id` DESC LIMIT 10 OFFSET 10"
+	if sql != want {
+		t.Fatalf("got  %s\nwant %s", sql, want)
+	}
+
+	var nilInt *int
+	if n := len(NewBuilder().Eq("a", nil).Eq("a", nilInt).Like("b", "").Between("c", "", nil).Page(0, 0).Build()); n != 0 {
+		t.Fatalf("expected all empty filters to be skipped, got %d scopes", n)
+	}
+
+	// 0、false 是有效的过滤值；指针取其指向的值；Between 的上下界颠倒时交换
+	zero, name := 0, "x"
+	sql = toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return NewBuilder().
+			Eq("status", 0).
+			Eq("enabled", false).
+			Eq("sort", &zero).
+			Eq("name", &name).
+			EqAlways("note", "").
+			EqAlways("deleted", nilInt).
+			Between("id", 10, 1).
+			Apply(tx.Model(&ZZ{})).
+			Find(&[]ZZ{})
+	})
+	want = "SELECT * FROM `zzs` WHERE `zzs`.`status` = 0 AND `zzs`.`enabled` = false AND `zzs`.`sort` = 0 AND `zzs`.`name` = \"x\" " +
+		"AND `zzs`.`note` = \"\" AND `zzs`.`deleted` IS NULL AND (`zzs`.`id` BETWEEN 1 AND 10)"
+	if sql != want {
+		t.Fatalf("got  %s\nwant %s", sql, want)
+	}
+}
@@ -0,0 +1,63 @@
+package gormx
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CaseExpr 根据映射生成一个简单 CASE 表达式：
+//
+//	(CASE keyColumn WHEN k1 THEN v1 WHEN k2 THEN v2 ... ELSE elseExpr END)
+//
+// WHEN 分支按键升序排列，以保证生成的 SQL 稳定。
+// 可用于按值映射进行条件更新或条件查询，例如：
+//
+//	db.Model(&User{}).Where("id IN ?", ids).Update("level", gormx.CaseExpr("id", levels, clause.Column{Name: "level"}))
+//
+// 参数:
+//
+//	keyColumn - 用于匹配的列名。
+//	mapping - 键为 keyColumn 的取值，值为对应的结果。
+//	elseExpr - 未匹配时的结果，为 nil 时省略 ELSE 分支（即结果为 NULL）。
+//
+// 返回值:
+//
+//	clause.Expr - 生成的 CASE 表达式。
+func CaseExpr[K cmp.Ordered, V any](keyColumn string, mapping map[K]V, elseExpr clause.Expression) clause.Expr {
+	var elseValue any
+	if elseExpr != nil {
+		elseValue = elseExpr
+	}
+	expr, _ := caseExpr(column(keyColumn), mapping, elseValue)
+	return expr
+}
+
+// caseExpr 构建 CASE 表达式，同时返回排序后的键，elseValue 为 nil 时省略 ELSE 分支。
+func caseExpr[K cmp.Ordered, V any](kc clause.Column, mapping map[K]V, elseValue any) (clause.Expr, []K) {
+	keys := make([]K, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	sql := bytes.NewBufferString(`(CASE ?`)
+	args := make([]any, 0, len(keys)*2+2)
+	args = append(args, kc)
+
+	for _, key := range keys {
+		sql.WriteString(` WHEN ? THEN ?`)
+		args = append(args, key, mapping[key])
+	}
+
+	if elseValue != nil {
+		sql.WriteString(` ELSE ?`)
+		args = append(args, elseValue)
+	}
+	sql.WriteString(` END)`)
+
+	return gorm.Expr(sql.String(), args...), keys
+}
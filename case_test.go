@@ -0,0 +1,35 @@
+package gormx
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestCaseExpr(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	tests := []struct {
+		expr clause.Expr
+		want string
+	}{
+		{
+			CaseExpr("id", map[int]string{2: "b", 1: "a"}, gorm.Expr("?", clause.Column{Name: "name"})),
+			"UPDATE `zzs` SET `note`=(CASE `zzs`.`id` WHEN 1 THEN \"a\" WHEN 2 THEN \"b\" ELSE `name` END) WHERE id > 0",
+		},
+		{
+			CaseExpr("code", map[string]int{"x": 1}, nil),
+			"UPDATE `zzs` SET `note`=(CASE `zzs`.`code` WHEN \"x\" THEN 1 END) WHERE id > 0",
+		},
+	}
+
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&ZZ{}).Where("id > 0").UpdateColumn("note", tt.expr)
+		})
+		if got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
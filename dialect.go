@@ -2,6 +2,9 @@ package gormx
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 
 	"gorm.io/gorm"
 )
@@ -9,6 +12,11 @@ import (
 var (
 	drivers     = map[string]func(string) gorm.Dialector{}
 	driverAlias = map[string]string{}
+	// driversMu 保护 drivers 和 driverAlias 的并发读写。
+	driversMu sync.RWMutex
+
+	// builtinDrivers 保存编译进来的内置驱动的注册函数，由各方言文件的 init 添加。
+	builtinDrivers []func()
 )
 
 type DialectOpen = func(string) gorm.Dialector
@@ -22,7 +30,12 @@ type DialectOpen = func(string) gorm.Dialector
 //	name - 驱动的唯一名称，用作在drivers map中的键。
 //	dialect - DialectOpen类型，表示特定数据库的方言实现。
 //	alias - 可变参数，包含驱动的别名，每个别名也将与主驱动名称建立映射。
+//
+// 重复注册同一名称或别名时会替换之前的注册，可用于在测试中把真实的方言替换为桩实现。
 func RegisterDriver(name string, dialect DialectOpen, alias ...string) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
 	// 将驱动名称与方言实现建立映射，以便后续可以通过驱动名称获取方言。
 	drivers[name] = dialect
 	// 遍历别名列表，将每个别名与主驱动名称建立映射，支持通过别名引用相同的方言。
@@ -35,20 +48,9 @@ func RegisterDriver(name string, dialect DialectOpen, alias ...string) {
 // 它接受数据库驱动名称、数据源名称（DSN）以及可选的 GORM 配置选项作为参数。
 // 函数返回一个 *gorm.DB 实例，用于与数据库进行交互，或者返回一个错误，如果连接失败。
 func Open(driver, dsn string, opts ...gorm.Option) (*gorm.DB, error) {
-	// 使用 driver 参数值初始化 name 变量，用于后续查找对应的数据库方言。
-	name := driver
-
-	// 尝试根据数据库名称获取对应的数据库方言构造函数。
-	dialect, ok := drivers[name]
-	// 如果没有找到对应的方言，检查是否存在该数据库驱动的别名。
-	if !ok {
-		// 如果存在别名，使用别名再次尝试获取数据库方言构造函数。
-		if name, ok = driverAlias[driver]; ok {
-			dialect, ok = drivers[name]
-		}
-	}
-
-	// 如果仍然没有找到对应的方言，返回一个未知驱动的错误。
+	// 根据驱动名称或别名查找对应的数据库方言构造函数。
+	dialect, ok := lookupDriver(driver)
+	// 如果没有找到对应的方言，返回一个未知驱动的错误。
 	if !ok {
 		return nil, fmt.Errorf("unknown driver: %s", driver)
 	}
@@ -57,3 +59,71 @@ func Open(driver, dsn string, opts ...gorm.Option) (*gorm.DB, error) {
 	// 同时传入所有的 GORM 配置选项。
 	return gorm.Open(dialect(dsn), opts...)
 }
+
+// HasDriver 判断名称或别名为 name 的驱动是否已注册，解析规则与 Open 相同。
+// 可以在启动时校验配置中的驱动名称，而不必等到打开连接时才发现驱动未编译进来。
+func HasDriver(name string) bool {
+	_, ok := lookupDriver(name)
+	return ok
+}
+
+// UnregisterDriver 移除名称为 name 的驱动以及所有指向它的别名，name 未注册时不做任何处理。
+// 内置驱动被移除后可以通过 RegisterAllBuiltin 恢复。
+func UnregisterDriver(name string) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	delete(drivers, name)
+	maps.DeleteFunc(driverAlias, func(_, target string) bool { return target == name })
+}
+
+// lookupDriver 根据驱动名称或别名查找数据库方言构造函数。
+func lookupDriver(driver string) (DialectOpen, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	if dialect, ok := drivers[driver]; ok {
+		return dialect, true
+	}
+	if name, ok := driverAlias[driver]; ok {
+		dialect, ok := drivers[name]
+		return dialect, ok
+	}
+	return nil, false
+}
+
+// registerBuiltin 记录并执行一个内置驱动的注册函数。
+func registerBuiltin(register func()) {
+	builtinDrivers = append(builtinDrivers, register)
+	register()
+}
+
+// RegisterAllBuiltin 重新注册所有编译进来的内置驱动（由构建标签决定，例如 sqlite、mysql、postgres、mssql）。
+// 内置驱动在包初始化时已经自动注册，该函数主要用于测试中恢复被覆盖的驱动注册，
+// 可以多次调用。
+func RegisterAllBuiltin() {
+	for _, register := range builtinDrivers {
+		register()
+	}
+}
+
+// ListDrivers 返回当前已注册的驱动名称，按名称排序，不包含别名。
+// 可用于排查构建标签未生效导致的 "unknown driver" 错误，返回的切片是一份拷贝。
+func ListDrivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return slices.Sorted(maps.Keys(drivers))
+}
+
+// DriverAliases 返回当前已注册的驱动别名，键为别名，值为对应的驱动名称。
+// 返回的 map 是一份拷贝，对它的修改不会影响已注册的别名。
+func DriverAliases() map[string]string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return maps.Clone(driverAlias)
+}
+
+// RegisteredDrivers 返回当前已注册的驱动名称，按名称排序，不包含别名。
+//
+// Deprecated: 使用 ListDrivers。
+func RegisteredDrivers() []string { return ListDrivers() }
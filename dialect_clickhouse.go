@@ -0,0 +1,7 @@
+//go:build clickhouse
+
+package gormx
+
+import "gorm.io/driver/clickhouse"
+
+func init() { registerBuiltin(func() { RegisterDriver("clickhouse", clickhouse.Open, "ch") }) }
@@ -0,0 +1,21 @@
+//go:build clickhouse
+
+package gormx
+
+import (
+	"testing"
+
+	"gorm.io/driver/clickhouse"
+)
+
+func TestClickHouseDriver(t *testing.T) {
+	for _, name := range []string{"clickhouse", "ch"} {
+		dialect, ok := lookupDriver(name)
+		if !ok {
+			t.Fatalf("%s driver not registered", name)
+		}
+		if d, ok := dialect("clickhouse://localhost:9000/default").(*clickhouse.Dialector); !ok || d.Name() != "clickhouse" {
+			t.Fatalf("%s: unexpected dialector: %T", name, dialect(""))
+		}
+	}
+}
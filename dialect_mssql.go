@@ -2,16 +2,11 @@
 
 package gormx
 
-import (
-	"gorm.io/driver/sqlserver"
-	"gorm.io/gorm"
-)
-
-func PostgresOpen(dsn string) gorm.Dialector {
-	return sqlserver.Open(dsn)
-}
+import "gorm.io/driver/sqlserver"
 
 func init() {
-	RegisterDriver("mssql", sqlserver.Open)
-	RegisterDriver("sqlserver", sqlserver.Open)
+	registerBuiltin(func() {
+		RegisterDriver("mssql", sqlserver.Open)
+		RegisterDriver("sqlserver", sqlserver.Open)
+	})
 }
@@ -0,0 +1,21 @@
+//go:build mssql
+
+package gormx
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlserver"
+)
+
+func TestSQLServerDriver(t *testing.T) {
+	for _, name := range []string{"mssql", "sqlserver"} {
+		dialect, ok := lookupDriver(name)
+		if !ok {
+			t.Fatalf("%s driver not registered", name)
+		}
+		if d, ok := dialect("sqlserver://localhost").(*sqlserver.Dialector); !ok || d.Name() != "sqlserver" {
+			t.Fatalf("%s: unexpected dialector: %T", name, dialect(""))
+		}
+	}
+}
@@ -3,7 +3,66 @@
 package gormx
 
 import (
+	"strings"
+
 	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
 )
 
-func init() { RegisterDriver("mysql", mysql.Open) }
+func init() { registerBuiltin(func() { RegisterDriver("mysql", mysql.Open, "mariadb") }) }
+
+// RegisterMySQLCompatible 注册一个兼容 MySQL 协议的数据库驱动，例如 TiDB、MariaDB、Vitess。
+//
+// serverVersion 为服务端版本号（即 SELECT VERSION() 的结果，如 "8.0.11-TiDB-v7.5.0"），
+// 指定后打开连接时不再查询版本，而是直接根据该版本设置方言的兼容选项；
+// 为空时与 mysql 驱动一致，在打开连接时查询服务端版本。
+//
+// 参数:
+//
+//	name - 驱动名称。
+//	serverVersion - 服务端版本号，可以为空。
+//	alias - 驱动的别名。
+func RegisterMySQLCompatible(name, serverVersion string, alias ...string) {
+	RegisterDriver(name, func(dsn string) gorm.Dialector {
+		return mysql.New(mysqlCompatibleConfig(dsn, serverVersion))
+	}, alias...)
+}
+
+// mysqlCompatibleConfig 根据服务端版本号生成 mysql 方言配置，兼容选项与 mysql 驱动查询版本后的设置一致。
+func mysqlCompatibleConfig(dsn, version string) mysql.Config {
+	c := mysql.Config{DSN: dsn}
+	if version == "" {
+		return c
+	}
+
+	c.ServerVersion = version
+	c.SkipInitializeWithVersion = true
+
+	switch {
+	case strings.Contains(version, "MariaDB"):
+		c.DontSupportRenameIndex = true
+		c.DontSupportRenameColumn = true
+		c.DontSupportForShareClause = true
+		c.DontSupportNullAsDefaultValue = true
+	case strings.HasPrefix(version, "5.6."):
+		c.DontSupportRenameIndex = true
+		c.DontSupportRenameColumn = true
+		c.DontSupportForShareClause = true
+		c.DontSupportDropConstraint = true
+	case strings.HasPrefix(version, "5.7."):
+		c.DontSupportRenameColumn = true
+		c.DontSupportForShareClause = true
+		c.DontSupportDropConstraint = true
+	case strings.HasPrefix(version, "5."):
+		c.DisableDatetimePrecision = true
+		c.DontSupportRenameIndex = true
+		c.DontSupportRenameColumn = true
+		c.DontSupportForShareClause = true
+		c.DontSupportDropConstraint = true
+	}
+
+	if strings.Contains(version, "TiDB") {
+		c.DontSupportRenameColumnUnique = true
+	}
+	return c
+}
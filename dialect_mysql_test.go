@@ -0,0 +1,37 @@
+//go:build mysql
+
+package gormx
+
+import (
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestRegisterMySQLCompatible(t *testing.T) {
+	RegisterMySQLCompatible("tidb", "8.0.11-TiDB-v7.5.0", "tidb7")
+
+	db, err := Open("tidb7", "root@tcp(localhost:4000)/test", &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, ok := db.Dialector.(*mysql.Dialector)
+	if !ok || d.Name() != "mysql" {
+		t.Fatalf("unexpected dialector: %T", db.Dialector)
+	}
+	if d.ServerVersion != "8.0.11-TiDB-v7.5.0" || !d.DontSupportRenameColumnUnique {
+		t.Fatalf("unexpected config: %+v", d.Config)
+	}
+}
+
+func TestMariaDBAlias(t *testing.T) {
+	dialect, ok := lookupDriver("mariadb")
+	if !ok {
+		t.Fatal("mariadb alias not registered")
+	}
+	if d, ok := dialect("root@tcp(localhost:3306)/test").(*mysql.Dialector); !ok || d.Name() != "mysql" {
+		t.Fatalf("unexpected dialector: %T", dialect(""))
+	}
+}
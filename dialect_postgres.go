@@ -4,10 +4,20 @@ package gormx
 
 import (
 	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
 func init() {
-	RegisterDriver("postgres", postgres.Open)
-	RegisterDriver("pg", postgres.Open)
-	RegisterDriver("postgresql", postgres.Open)
+	registerBuiltin(func() {
+		RegisterDriver("postgres", postgres.Open)
+		RegisterDriver("pg", postgres.Open)
+		RegisterDriver("postgresql", postgres.Open)
+		RegisterDriver("pgx", PgxOpen, "pgx/v5")
+	})
+}
+
+// PgxOpen 返回一个显式使用 pgx 标准库驱动（github.com/jackc/pgx/v5/stdlib）的 postgres 方言，
+// 注册为 "pgx" 驱动，也可以通过别名 "pgx/v5" 打开。
+func PgxOpen(dsn string) gorm.Dialector {
+	return postgres.New(postgres.Config{DriverName: "pgx", DSN: dsn})
 }
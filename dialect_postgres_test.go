@@ -0,0 +1,133 @@
+//go:build postgres
+
+package gormx
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestPgxDriver(t *testing.T) {
+	if !slices.Contains(ListDrivers(), "pgx") {
+		t.Fatal("pgx driver not registered")
+	}
+
+	for _, name := range []string{"pgx", "pgx/v5"} {
+		db, err := Open(name, "host=localhost user=gormx dbname=gormx", &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, ok := db.Dialector.(*postgres.Dialector)
+		if !ok || d.Name() != "postgres" || d.DriverName != "pgx" {
+			t.Fatalf("%s: unexpected dialector: %#v", name, db.Dialector)
+		}
+	}
+
+	dsn := os.Getenv("GORMX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GORMX_TEST_POSTGRES_DSN not set")
+	}
+	db, err := Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Exec("SELECT 1").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpsertOnWherePartialIndex(t *testing.T) {
+	dsn := os.Getenv("GORMX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GORMX_TEST_POSTGRES_DSN not set")
+	}
+	db, err := Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type upsertItem struct {
+		ID      int
+		Code    string
+		Name    string
+		Deleted bool
+	}
+	if err = db.Migrator().DropTable(&upsertItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&upsertItem{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Migrator().DropTable(&upsertItem{}) })
+	if err = db.Exec("CREATE UNIQUE INDEX upsert_items_code_live ON upsert_items (code) WHERE NOT deleted").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := UpsertOnWhere([]string{"code"}, gorm.Expr("NOT deleted"), "name")
+	db.Create(&upsertItem{ID: 1, Code: "a", Name: "old", Deleted: true})
+	for i, name := range []string{"x", "y"} {
+		if err = db.Scopes(upsert).Create(&upsertItem{ID: 2 + i, Code: "a", Name: name}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var items []upsertItem
+	db.Order("id").Find(&items)
+	if len(items) != 2 || items[1].Name != "y" || items[0].Name != "old" {
+		t.Fatalf("unexpected rows: %+v", items)
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	dsn := os.Getenv("GORMX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GORMX_TEST_POSTGRES_DSN not set")
+	}
+	setOptions(t, func(string) Options { return Options{Driver: "postgres", DSN: dsn, Timezone: "UTC"} })
+
+	db, err := Create("timezone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+
+	var tz string
+	if err = db.Raw("SHOW TIMEZONE").Scan(&tz).Error; err != nil {
+		t.Fatal(err)
+	}
+	if tz != "UTC" {
+		t.Fatalf("session timezone = %q, want UTC", tz)
+	}
+}
+
+func TestInLargePostgres(t *testing.T) {
+	dsn := os.Getenv("GORMX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GORMX_TEST_POSTGRES_DSN not set")
+	}
+	db, err := Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Migrator().DropTable(&ZZ{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&ZZ{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Migrator().DropTable(&ZZ{}) })
+	seedSort(t, db, 10)
+
+	old := inLargeThreshold
+	inLargeThreshold = 2
+	t.Cleanup(func() { inLargeThreshold = old })
+
+	var rows []ZZ
+	if err = db.Scopes(InLarge("id", []int{2, 4, 6, 8, 10, 12})).Find(&rows).Error; err != nil || len(rows) != 5 {
+		t.Fatalf("rows = %d, err = %v, want 5", len(rows), err)
+	}
+}
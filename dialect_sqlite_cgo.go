@@ -1,4 +1,4 @@
-//go:build (!mssql && !mysql && !postgres && !sqlite) || (sqlite && cgo)
+//go:build (!clickhouse && !mssql && !mysql && !postgres && !sqlite) || (sqlite && cgo)
 
 package gormx
 
@@ -6,4 +6,5 @@ import (
 	"gorm.io/driver/sqlite"
 )
 
-func init() { RegisterDriver("sqlite", sqlite.Open) }
+// mattn/go-sqlite3 在 DSN 未指定 _busy_timeout 时默认使用 5000 毫秒，无需额外处理。
+func init() { registerBuiltin(func() { RegisterDriver("sqlite", sqlite.Open) }) }
@@ -3,8 +3,40 @@
 package gormx
 
 import (
+	"fmt"
+	"strings"
+
 	_ "github.com/ncruces/go-sqlite3/embed"
 	sqlite "github.com/ncruces/go-sqlite3/gormlite"
+	"gorm.io/gorm"
 )
 
-func init() { RegisterDriver("sqlite", sqlite.Open) }
+// sqliteBusyTimeout 是 sqlite 连接默认的 busy_timeout，单位为毫秒。
+const sqliteBusyTimeout = 5000
+
+func init() {
+	registerBuiltin(func() {
+		RegisterDriver("sqlite", func(dsn string) gorm.Dialector { return sqlite.Open(sqliteDSN(dsn)) })
+	})
+}
+
+// sqliteDSN 为没有指定 busy_timeout 的 DSN 加上默认值，避免并发写入时出现 "database is locked"。
+//
+// ncruces/go-sqlite3 只有在 DSN 中没有任何 _pragma 时才会设置默认的 busy_timeout，
+// 并且只解析以 "file:" 开头的 DSN 中的参数，因此这里统一转换为 URI 形式，
+// 并把 busy_timeout 放在第一个 _pragma，保证它先于其他 PRAGMA 生效。
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "busy_timeout") {
+		return dsn
+	}
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+
+	base, query, _ := strings.Cut(dsn, "?")
+	pragma := fmt.Sprintf("_pragma=busy_timeout(%d)", sqliteBusyTimeout)
+	if query != "" {
+		pragma += "&" + query
+	}
+	return base + "?" + pragma
+}
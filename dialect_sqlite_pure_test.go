@@ -0,0 +1,22 @@
+//go:build sqlite && !cgo
+
+package gormx
+
+import "testing"
+
+func init() { skipLargeCaseSort = true }
+
+func TestSqliteDSN(t *testing.T) {
+	tests := map[string]string{
+		":memory:":                               "file::memory:?_pragma=busy_timeout(5000)",
+		"data.db":                                "file:data.db?_pragma=busy_timeout(5000)",
+		"file:data.db?_pragma=journal_mode(WAL)": "file:data.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+		"file:data.db?_pragma=busy_timeout(100)": "file:data.db?_pragma=busy_timeout(100)",
+	}
+
+	for in, want := range tests {
+		if got := sqliteDSN(in); got != want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
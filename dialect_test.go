@@ -0,0 +1,190 @@
+package gormx
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestSqliteConcurrentWrites(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip concurrent writes in short mode")
+	}
+
+	dsn := filepath.Join(t.TempDir(), "concurrent.db")
+
+	db, err := Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&ZZ{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// 每个 goroutine 使用独立的连接池，模拟多个进程同时写入。
+			conn, err := Open("sqlite", dsn)
+			if err == nil {
+				for i := 0; i < 20 && err == nil; i++ {
+					err = conn.Create(&ZZ{ID: w*100 + i + 1, Sort: i}).Error
+				}
+				if sqlDB, e := conn.DB(); e == nil {
+					sqlDB.Close()
+				}
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("writer %d: %w", w, err))
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	var count int64
+	db.Model(&ZZ{}).Count(&count)
+	if count != 160 {
+		t.Fatalf("count = %d, want 160", count)
+	}
+}
+
+func TestRegisterAllBuiltin(t *testing.T) {
+	old := drivers["sqlite"]
+	t.Cleanup(func() { drivers["sqlite"] = old })
+
+	delete(drivers, "sqlite")
+	RegisterAllBuiltin()
+
+	names := ListDrivers()
+	if len(names) == 0 || !slices.Contains(names, "sqlite") {
+		t.Fatalf("registered drivers = %v, want sqlite", names)
+	}
+	if !slices.IsSorted(names) {
+		t.Fatalf("registered drivers not sorted: %v", names)
+	}
+	if got := RegisteredDrivers(); !slices.Equal(got, names) {
+		t.Fatalf("RegisteredDrivers() = %v, want %v", got, names)
+	}
+}
+
+func TestDriverAliases(t *testing.T) {
+	RegisterDriver("alias_test", drivers["sqlite"], "alias_a", "alias_b")
+	t.Cleanup(func() {
+		delete(drivers, "alias_test")
+		delete(driverAlias, "alias_a")
+		delete(driverAlias, "alias_b")
+	})
+
+	aliases := DriverAliases()
+	if aliases["alias_a"] != "alias_test" || aliases["alias_b"] != "alias_test" {
+		t.Fatalf("aliases = %v", aliases)
+	}
+	if slices.Contains(ListDrivers(), "alias_a") {
+		t.Fatal("ListDrivers should not include aliases")
+	}
+
+	delete(aliases, "alias_a")
+	names := ListDrivers()
+	names[0] = "changed"
+	if _, ok := lookupDriver("alias_a"); !ok || slices.Contains(ListDrivers(), "changed") {
+		t.Fatal("returned values should be copies")
+	}
+}
+
+func TestHasDriver(t *testing.T) {
+	RegisterDriver("has_test", drivers["sqlite"], "has_alias")
+	t.Cleanup(func() {
+		delete(drivers, "has_test")
+		delete(driverAlias, "has_alias")
+		delete(driverAlias, "dangling")
+	})
+	driverAlias["dangling"] = "missing"
+
+	for name, want := range map[string]bool{"sqlite": true, "has_test": true, "has_alias": true, "dangling": false, "unknown": false} {
+		if got := HasDriver(name); got != want {
+			t.Errorf("HasDriver(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestUnregisterDriver(t *testing.T) {
+	stub := func(string) gorm.Dialector { return nil }
+	RegisterDriver("unreg_test", drivers["sqlite"], "unreg_a", "unreg_b")
+	RegisterDriver("unreg_other", drivers["sqlite"], "unreg_c")
+	t.Cleanup(func() { UnregisterDriver("unreg_other") })
+
+	RegisterDriver("unreg_test", stub)
+	if d, _ := lookupDriver("unreg_a"); d("") != nil {
+		t.Fatal("re-registering should replace the dialect")
+	}
+
+	UnregisterDriver("unreg_test")
+	for _, name := range []string{"unreg_test", "unreg_a", "unreg_b"} {
+		if HasDriver(name) {
+			t.Errorf("%s still registered", name)
+		}
+	}
+	if !HasDriver("unreg_c") {
+		t.Error("unrelated alias removed")
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("unreg_race_%d", i)
+			RegisterDriver(name, stub, name+"_alias")
+			_ = HasDriver(name + "_alias")
+			_ = ListDrivers()
+			UnregisterDriver(name)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRegisterDriverConcurrentOpen(t *testing.T) {
+	sqlite, _ := lookupDriver("sqlite")
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterDriver(fmt.Sprintf("race_open_%d", i), sqlite, fmt.Sprintf("race_open_alias_%d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			db, err := Open("sqlite", filepath.Join(dir, fmt.Sprintf("race_%d.db", i)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 8 {
+		UnregisterDriver(fmt.Sprintf("race_open_%d", i))
+	}
+}
@@ -1,9 +1,14 @@
 package gormx
 
 import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -33,6 +38,244 @@ func SetOptionsFunc(fn func(name string) Options) { getOptions = fn }
 //	prefix: 要设置的新环境变量前缀。这应该是一个简洁且具有描述性的字符串，用于标识项目或应用程序的环境变量。
 func SetEnvPrefix(prefix string) { envPrefix = prefix }
 
+// BindOptions 从配置结构体中读取数据库配置，并注册为配置来源。
+// 结构体中带有 `gormx:"driver"`、`gormx:"dsn"`、`gormx:"debug"` 等标签的字段
+// 会被填入对应的 Options 字段，标签名与 Options 的 json 标签一致。
+// 连接名称由嵌套结构体字段的标签指定，例如 `gormx:"primary"`；未指定名称时为默认连接。
+// 未绑定的连接名称仍然从环境变量读取。
+//
+//	type DB struct {
+//		Driver string `gormx:"driver"`
+//		DSN    string `gormx:"dsn"`
+//	}
+//	type Config struct {
+//		Main   DB `gormx:"default"`
+//		Report DB `gormx:"report"`
+//	}
+//
+// 参数:
+//
+//	v - 配置结构体或指向结构体的指针。
+//
+// 返回值:
+//
+//	error - v 不是结构体或字段类型无法转换时返回错误。
+func BindOptions(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("bind options: expected struct, got %T", v)
+	}
+
+	m := map[string]Options{}
+	if err := bindOptions(rv, DEFAULT, m); err != nil {
+		return err
+	}
+	setOptionsMap(m)
+	return nil
+}
+
+// SetOptionsFromFile 从 JSON 配置文件中读取数据库配置，并注册为配置来源。
+// 文件可以是连接名称到配置的映射，默认连接的名称为 "default"（不区分大小写）；
+// 也可以直接是一个配置对象，此时作为默认连接的配置。
+// 配置的键与 Options 的 json 标签一致，时长可以写成 "30s" 这样的字符串，数字也可以写成字符串。
+// 文件中不存在的连接名称仍然从环境变量读取。
+//
+//	{
+//		"default": {"driver": "mysql", "dsn": "...", "conn_max_lifetime": "1h"},
+//		"report": {"driver": "postgres", "dsn": "..."}
+//	}
+//
+// 参数:
+//
+//	path - 配置文件路径。
+//
+// 返回值:
+//
+//	error - 文件不存在、不是合法的 JSON 或包含未知的配置项时返回错误，此时不修改配置来源。
+func SetOptionsFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read options file: %w", err)
+	}
+
+	var top map[string]json.RawMessage
+	if err = json.Unmarshal(data, &top); err != nil {
+		return fmt.Errorf("parse options file %s: %w", path, err)
+	}
+
+	m := map[string]Options{}
+	if isOptionsObject(top) {
+		if m[DEFAULT], err = decodeOptions(top); err != nil {
+			return fmt.Errorf("parse options file %s: %w", path, err)
+		}
+		setOptionsMap(m)
+		return nil
+	}
+
+	for name, raw := range top {
+		var fields map[string]json.RawMessage
+		if err = json.Unmarshal(raw, &fields); err != nil {
+			return fmt.Errorf("parse options file %s: connection %q: %w", path, name, err)
+		}
+		if m[optionsName(name)], err = decodeOptions(fields); err != nil {
+			return fmt.Errorf("parse options file %s: connection %q: %w", path, name, err)
+		}
+	}
+	setOptionsMap(m)
+	return nil
+}
+
+// isOptionsObject 判断 JSON 对象是否直接是一个配置，而不是连接名称到配置的映射。
+func isOptionsObject(fields map[string]json.RawMessage) bool {
+	for key := range fields {
+		if _, ok := optionFields[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeOptions 把 JSON 对象的各个键解析到 Options 对应的字段。
+// 非字符串字段的值为 JSON 字符串时按 setOptionField 的规则转换，例如 "30s" 转换为时长。
+func decodeOptions(fields map[string]json.RawMessage) (opts Options, err error) {
+	ov := reflect.ValueOf(&opts).Elem()
+	for key, raw := range fields {
+		idx, ok := optionFields[key]
+		if !ok {
+			return opts, fmt.Errorf("unknown option %q", key)
+		}
+
+		fv := ov.Field(idx)
+		var str string
+		if fv.Kind() != reflect.String && json.Unmarshal(raw, &str) == nil {
+			err = setOptionField(fv, reflect.ValueOf(str))
+		} else {
+			err = json.Unmarshal(raw, fv.Addr().Interface())
+		}
+		if err != nil {
+			return opts, fmt.Errorf("option %q: %w", key, err)
+		}
+	}
+	return opts, nil
+}
+
+// setOptionsMap 以 m 作为配置来源，m 中不存在的名称回退到环境变量。
+func setOptionsMap(m map[string]Options) {
+	SetOptionsFunc(func(name string) Options {
+		if opts, ok := m[optionsName(name)]; ok {
+			return opts
+		}
+		return defaultOptions(name)
+	})
+}
+
+func optionsName(name string) string {
+	if name == "" || strings.EqualFold(name, DEFAULT) {
+		return DEFAULT
+	}
+	return name
+}
+
+// optionFields 是 Options 的 json 标签名到字段下标的映射。
+var optionFields = func() map[string]int {
+	m := map[string]int{}
+	t := reflect.TypeFor[Options]()
+	for i := range t.NumField() {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); tag != "-" {
+			m[tag] = i
+		}
+	}
+	return m
+}()
+
+func bindOptions(rv reflect.Value, name string, m map[string]Options) error {
+	var (
+		opts  Options
+		found bool
+		ov    = reflect.ValueOf(&opts).Elem()
+	)
+
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("gormx")
+		fv := reflect.Indirect(rv.Field(i))
+
+		if idx, ok := optionFields[tag]; ok {
+			if !fv.IsValid() {
+				continue
+			}
+			if err := setOptionField(ov.Field(idx), fv); err != nil {
+				return fmt.Errorf("bind options: field %s: %w", f.Name, err)
+			}
+			found = true
+			continue
+		}
+
+		if fv.Kind() == reflect.Struct && tag != "-" {
+			sub := name
+			if tag != "" {
+				sub = optionsName(tag)
+			}
+			if err := bindOptions(fv, sub, m); err != nil {
+				return err
+			}
+		}
+	}
+
+	if found {
+		m[name] = opts
+	}
+	return nil
+}
+
+func setOptionField(dst, src reflect.Value) error {
+	if src.Kind() == reflect.String && dst.Kind() != reflect.String {
+		v := src.String()
+		if v == "" {
+			return nil
+		}
+		switch {
+		case dst.Type() == reflect.TypeFor[time.Duration]():
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return err
+			}
+			dst.SetInt(int64(d))
+			return nil
+		case dst.Kind() == reflect.Int:
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			dst.SetInt(int64(n))
+			return nil
+		case dst.Kind() == reflect.Float64:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(f)
+			return nil
+		case dst.Kind() == reflect.Bool:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			dst.SetBool(b)
+			return nil
+		}
+	}
+	if !src.Type().ConvertibleTo(dst.Type()) {
+		return fmt.Errorf("cannot convert %s to %s", src.Type(), dst.Type())
+	}
+	dst.Set(src.Convert(dst.Type()))
+	return nil
+}
+
 func getOpts(name string) Options {
 	get := getOptions
 	if get == nil {
@@ -41,14 +284,45 @@ func getOpts(name string) Options {
 	return get(name)
 }
 
+// defaultOptions 从环境变量读取名称为 name 的连接配置，变量名的规则见 fromEnv。
+// 数字、布尔值和时长无法解析时输出警告并忽略该变量，使用零值。
 func defaultOptions(name string) (opts Options) {
 	opts.Driver = fromEnv("DRIVER", name)
 	opts.DSN = fromEnv("DSN", name)
-	opts.Debug, _ = strconv.ParseBool(fromEnv("DEBUG", name))
+	opts.Debug = parseEnv("DEBUG", name, strconv.ParseBool)
+	opts.DisableAutomaticPing = parseEnv("DISABLE_PING", name, strconv.ParseBool)
+	opts.Charset = fromEnv("CHARSET", name)
+	opts.Collation = fromEnv("COLLATION", name)
+	opts.Timezone = fromEnv("TIMEZONE", name)
+	opts.MaxOpenConns = parseEnv("MAX_OPEN_CONNS", name, strconv.Atoi)
+	opts.MaxIdleConns = parseEnv("MAX_IDLE_CONNS", name, strconv.Atoi)
+	opts.ConnMaxLifetime = parseEnv("CONN_MAX_LIFETIME", name, time.ParseDuration)
+	opts.ConnMaxIdleTime = parseEnv("CONN_MAX_IDLE_TIME", name, time.ParseDuration)
+	opts.ConnMaxLifetimeJitter = parseEnv("CONN_MAX_LIFETIME_JITTER", name, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
 	return
 }
 
-func fromEnv(field, name string) string {
+// parseEnv 读取环境变量并用 parse 解析，变量为空时返回零值，解析失败时输出警告并返回零值。
+func parseEnv[T any](field, name string, parse func(string) (T, error)) T {
+	var zero T
+	key := envKey(field, name)
+	s := os.Getenv(key)
+	if s == "" {
+		return zero
+	}
+	v, err := parse(s)
+	if err != nil {
+		slog.Warn("[sql] invalid environment variable, ignored", "key", key, "value", s, "err", err)
+		return zero
+	}
+	return v
+}
+
+func fromEnv(field, name string) string { return os.Getenv(envKey(field, name)) }
+
+// envKey 返回字段 field 在连接 name 下的环境变量名，例如 DB_DSN、DB_DSN_MYDB，
+// 设置了前缀时为 PREFIX_DB_DSN_MYDB。默认连接的变量名没有名称后缀。
+func envKey(field, name string) string {
 	if name == DEFAULT || name == "" {
 		name = ""
 	} else {
@@ -66,5 +340,5 @@ func fromEnv(field, name string) string {
 		p += "_"
 	}
 
-	return os.Getenv(p + name)
+	return p + name
 }
@@ -0,0 +1,148 @@
+package gormx
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBindOptions(t *testing.T) {
+	type db struct {
+		Driver string `gormx:"driver"`
+		DSN    string `gormx:"dsn"`
+		Debug  string `gormx:"debug"`
+		TTL    string `gormx:"conn_max_lifetime"`
+	}
+	type config struct {
+		Addr   string
+		Main   db  `gormx:"default"`
+		Report *db `gormx:"report"`
+		Skip   db  `gormx:"-"`
+	}
+
+	setOptions(t, nil)
+	err := BindOptions(&config{
+		Main:   db{Driver: "sqlite", DSN: "main.db"},
+		Report: &db{Driver: "postgres", DSN: "host=report", Debug: "true", TTL: "5m"},
+		Skip:   db{Driver: "mysql"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := getOpts(""); !reflect.DeepEqual(got, Options{Driver: "sqlite", DSN: "main.db"}) {
+		t.Errorf("default: %+v", got)
+	}
+	if got := getOpts("report"); !reflect.DeepEqual(got, Options{Driver: "postgres", DSN: "host=report", Debug: true, ConnMaxLifetime: 5 * time.Minute}) {
+		t.Errorf("report: %+v", got)
+	}
+
+	t.Setenv("DB_DSN_OTHER", "other.db")
+	if got := getOpts("other"); got.DSN != "other.db" {
+		t.Errorf("fallback to env: %+v", got)
+	}
+
+	if err := BindOptions("x"); err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
+
+func TestSetOptionsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	setOptions(t, nil)
+	err := SetOptionsFromFile(write("multi.json", `{
+		"DEFAULT": {"driver": "sqlite", "dsn": "main.db"},
+		"report": {"driver": "postgres", "dsn": "host=report", "debug": true, "conn_max_lifetime": "5m", "max_open_conns": 8, "replicas": ["host=r1"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getOpts(""); !reflect.DeepEqual(got, Options{Driver: "sqlite", DSN: "main.db"}) {
+		t.Errorf("default: %+v", got)
+	}
+	want := Options{Driver: "postgres", DSN: "host=report", Debug: true, ConnMaxLifetime: 5 * time.Minute, MaxOpenConns: 8, Replicas: []string{"host=r1"}}
+	if got := getOpts("report"); !reflect.DeepEqual(got, want) {
+		t.Errorf("report: %+v", got)
+	}
+	t.Setenv("DB_DSN_OTHER", "other.db")
+	if got := getOpts("other"); got.DSN != "other.db" {
+		t.Errorf("fallback to env: %+v", got)
+	}
+
+	if err := SetOptionsFromFile(write("single.json", `{"driver": "mysql", "dsn": "single", "max_idle_conns": "4"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := getOpts("default"); !reflect.DeepEqual(got, Options{Driver: "mysql", DSN: "single", MaxIdleConns: 4}) {
+		t.Errorf("single: %+v", got)
+	}
+
+	for _, path := range []string{
+		filepath.Join(dir, "missing.json"),
+		write("broken.json", `{"default": `),
+		write("unknown.json", `{"driver": "mysql", "port": 3306}`),
+		write("badvalue.json", `{"default": {"conn_max_lifetime": "soon"}}`),
+	} {
+		if err := SetOptionsFromFile(path); err == nil {
+			t.Errorf("%s: expected error", filepath.Base(path))
+		}
+	}
+	if got := getOpts("default"); got.DSN != "single" {
+		t.Errorf("failed load should keep previous options: %+v", got)
+	}
+}
+
+func TestDefaultOptionsCharset(t *testing.T) {
+	t.Setenv("DB_CHARSET_MY", "utf8mb4")
+	t.Setenv("DB_COLLATION_MY", "utf8mb4_unicode_ci")
+	t.Setenv("DB_TIMEZONE_MY", "UTC")
+
+	if got := defaultOptions("my"); got.Charset != "utf8mb4" || got.Collation != "utf8mb4_unicode_ci" || got.Timezone != "UTC" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestDefaultOptionsPool(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS_POOL", "20")
+	t.Setenv("DB_MAX_IDLE_CONNS_POOL", "5")
+	t.Setenv("DB_CONN_MAX_LIFETIME_POOL", "30m")
+	t.Setenv("DB_CONN_MAX_IDLE_TIME_POOL", "1m")
+	t.Setenv("DB_CONN_MAX_LIFETIME_JITTER_POOL", "0.1")
+
+	got := defaultOptions("pool")
+	want := Options{MaxOpenConns: 20, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: time.Minute, ConnMaxLifetimeJitter: 0.1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	t.Setenv("DB_MAX_OPEN_CONNS_BAD", "many")
+	t.Setenv("DB_CONN_MAX_LIFETIME_BAD", "30")
+	t.Setenv("DB_CONN_MAX_LIFETIME_JITTER_BAD", "10%")
+	t.Setenv("DB_DEBUG_BAD", "yes please")
+	t.Setenv("DB_MAX_IDLE_CONNS_BAD", "2")
+	got = defaultOptions("bad")
+	if want := (Options{MaxIdleConns: 2}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for _, key := range []string{"DB_MAX_OPEN_CONNS_BAD", "DB_CONN_MAX_LIFETIME_BAD", "DB_CONN_MAX_LIFETIME_JITTER_BAD", "DB_DEBUG_BAD"} {
+		if !bytes.Contains(buf.Bytes(), []byte("key="+key)) {
+			t.Errorf("missing warning for %s: %s", key, buf.String())
+		}
+	}
+}
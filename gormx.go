@@ -1,13 +1,27 @@
 package gormx
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
-var fetch = SingleWrap(Create)
+var (
+	conns = NewSingle(Create)
+	fetch = conns.Get
+
+	// defaultScopes 保存各连接的默认查询范围，键为连接名称，值为 []Scope。
+	defaultScopes sync.Map
+)
 
 // Options 定义了数据库连接的配置选项。
 // 它是一个结构体，包含了连接数据库所需的信息以及调试模式的配置。
@@ -25,11 +39,64 @@ type Options struct {
 	// 当设置为 true 时，数据库操作的相关信息会被记录下来，通常用于开发或者调试阶段。
 	// 在生产环境中，通常将这个值设置为 false，以避免不必要的性能开销。
 	Debug bool `json:"debug,omitempty"`
+
+	// DisableAutomaticPing 为 true 时打开连接后不执行 gorm 默认的 Ping 检查，
+	// 适用于在初始化时不希望建立连接的代理或负载均衡环境。
+	DisableAutomaticPing bool `json:"disable_automatic_ping,omitempty"`
+
+	// MaxOpenConns 是连接池的最大打开连接数，0 表示使用驱动默认值（不限制）。
+	MaxOpenConns int `json:"max_open_conns,omitempty"`
+
+	// MaxIdleConns 是连接池的最大空闲连接数，0 表示使用驱动默认值。
+	MaxIdleConns int `json:"max_idle_conns,omitempty"`
+
+	// ConnMaxLifetime 是连接可被复用的最长时间，0 表示不限制。
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime,omitempty"`
+
+	// ConnMaxIdleTime 是连接的最长空闲时间，超过后会被关闭，0 表示不限制。
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time,omitempty"`
+
+	// ConnMaxLifetimeJitter 是 ConnMaxLifetime 的随机浮动比例，取值 0 到 1，例如 0.1 表示上下浮动 10%。
+	// 多个连接同时设置相同的 ConnMaxLifetime 时，加入浮动可以错开连接过期重建的时间。
+	// 浮动在打开连接池时计算一次，同一个连接池中的连接使用相同的最长存活时间，不支持逐个连接浮动；
+	// 浮动错开的是多个连接池（多个进程、主库与各个副本）之间的过期时间。0 表示不浮动。
+	ConnMaxLifetimeJitter float64 `json:"conn_max_lifetime_jitter,omitempty"`
+
+	// Charset 是 mysql 连接的字符集，例如 "utf8mb4"。
+	// 只在驱动为 mysql（或兼容 mysql 的驱动）且 DSN 中没有 charset 参数时写入 DSN，为空时不修改 DSN。
+	Charset string `json:"charset,omitempty"`
+
+	// Collation 是 mysql 连接的排序规则，例如 "utf8mb4_unicode_ci"，规则同 Charset。
+	Collation string `json:"collation,omitempty"`
+
+	// Timezone 是连接的会话时区，例如 "UTC" 或 "Asia/Shanghai"，为空时使用数据库服务器的设置。
+	// 时区写入 DSN，使连接池中的每个连接在建立时都设置该时区：
+	// postgres 写入 timezone 参数；mysql 写入 time_zone 参数（即 SET time_zone），
+	// 是合法的 IANA 时区名时同时写入 loc，使驱动按该时区解析时间。DSN 中已有同名参数时不覆盖。
+	// mysql 服务器只有加载了时区表（mysql_tzinfo_to_sql）才能识别 "Asia/Shanghai" 这样的时区名，
+	// 否则建立连接时报错，此时应使用 "+08:00" 这样的偏移量；"UTC" 会自动写成 "+00:00"。
+	// 其他数据库不支持会话时区，设置时输出警告并忽略。
+	Timezone string `json:"timezone,omitempty"`
+
+	// Replicas 是只读副本的 DSN 列表，不为空时查询会随机发送到其中一个副本，写操作和事务仍使用主库（DSN），
+	// 通过 gorm 的 dbresolver 插件实现，路由规则即 dbresolver 的默认行为。
+	// 副本使用与主库相同的连接池参数，字符集、排序规则和会话时区也按相同的规则写入副本的 DSN。
+	Replicas []string `json:"replicas,omitempty"`
+
+	// ReplicaDriver 是副本使用的驱动名称，为空时与 Driver 相同。
+	ReplicaDriver string `json:"replica_driver,omitempty"`
+
+	// AfterOpen 在连接打开并应用上述配置之后调用，可用于为单个连接注册插件、回调等。
+	// 返回错误时连接会被关闭，Create 返回该错误。
+	AfterOpen func(*gorm.DB) error `json:"-"`
 }
 
 // Default 返回一个默认的 *gorm.DB 实例，主要用于数据库操作。
 // 该函数尝试通过调用 fetch 函数来获取数据库实例。如果 fetch 函数返回错误，
 // 则构建一个带有错误信息的 *gorm.DB 实例，并确保其内部状态正确初始化。
+//
+// 创建失败的连接不会被缓存，下一次调用 Default 或 Get 会重新尝试创建，
+// 因此在数据库尚未就绪时调用不会使错误一直保留；需要等待数据库就绪时可以使用 DefaultWithRetry。
 func Default() *gorm.DB {
 	// 尝试调用 fetch 函数来获取数据库实例。
 	d, err := fetch("")
@@ -44,6 +111,37 @@ func Default() *gorm.DB {
 	return d
 }
 
+// DefaultWithRetry 与 Default 相同，但在获取失败时最多尝试 attempts 次，
+// 两次尝试之间等待 backoff，并且每次等待时间翻倍。
+// attempts 小于 1 时只尝试一次。所有尝试都失败时返回带有最后一次错误的 *gorm.DB。
+//
+// 参数:
+//
+//	attempts - 最多尝试的次数。
+//	backoff - 第一次重试前的等待时间。
+func DefaultWithRetry(attempts int, backoff time.Duration) *gorm.DB {
+	d, err := getWithRetry("", attempts, backoff)
+	if err != nil {
+		d = &gorm.DB{Error: err, Config: &gorm.Config{}}
+		d.Statement = &gorm.Statement{DB: d}
+	}
+	return d
+}
+
+func getWithRetry(name string, attempts int, backoff time.Duration) (d *gorm.DB, err error) {
+	for i := range max(attempts, 1) {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if d, err = fetch(name); err == nil {
+			return d, nil
+		}
+		slog.Debug("[sql] open failed", "name", name, "attempt", i+1, "err", err)
+	}
+	return nil, err
+}
+
 // Get 是一个用于获取数据库连接的函数。
 // 它接受一个数据库名称作为参数，并返回一个指向 gorm.DB 的指针和一个错误值。
 // 该函数主要负责调用 fetch 函数来实际进行数据库连接的获取。
@@ -60,6 +158,87 @@ func Get(name string) (*gorm.DB, error) {
 	return fetch(name)
 }
 
+// MustGet 与 Get 相同，但在获取数据库连接失败时直接 panic。
+// 适用于初始化阶段，数据库不可用即视为致命错误的场景。
+func MustGet(name string) *gorm.DB {
+	d, err := Get(name)
+	if err != nil {
+		if name == "" {
+			name = DEFAULT
+		}
+		panic(fmt.Sprintf("gormx: get database %q: %v", name, err))
+	}
+	return d
+}
+
+// Ping 检查指定连接的数据库是否可达，适合用于就绪探针等健康检查。
+// 连接尚未创建时会先创建连接。
+//
+// 参数:
+//
+//	ctx - 控制检查超时或取消的上下文。
+//	name - 连接名称，为空时表示默认连接。
+//
+// 返回值:
+//
+//	error - 获取连接或检查失败时返回带有连接名称的错误。
+func Ping(ctx context.Context, name string) error {
+	d, err := Get(name)
+	if err == nil {
+		var sqlDB *sql.DB
+		if sqlDB, err = d.DB(); err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("ping database %q: %w", optionsName(name), err)
+	}
+	return nil
+}
+
+// ReadOnly 返回指定连接上带有只读标记的会话，用于只读查询。
+// 标记即 gorm.io/plugin/dbresolver 的 dbresolver.Read，
+// 当连接配置了 Options.Replicas 或注册了 dbresolver 插件时，该会话上的查询会被路由到只读副本；
+// 单库部署时它与普通会话的行为相同。
+//
+// 参数:
+//
+//	name - 连接名称，为空时表示默认连接。
+//
+// 返回值:
+//
+//	*gorm.DB - 带有只读标记的新会话。
+//	error - 获取数据库连接失败时返回错误。
+func ReadOnly(name string) (*gorm.DB, error) {
+	d, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return d.Clauses(dbresolver.Read).Session(&gorm.Session{}), nil
+}
+
+// SafeDSN 返回指定连接配置中隐藏了密码的 DSN，适合在管理界面或日志中展示。
+// 它只读取配置，不会打开数据库连接。
+//
+// 参数:
+//
+//	name - 连接名称，为空时表示默认连接。
+//
+// 返回值:
+//
+//	string - 隐藏密码后的 DSN。
+//	error - 该连接没有配置 DSN 时返回错误。
+func SafeDSN(name string) (string, error) {
+	opts := getOpts(name)
+	if opts.DSN == "" {
+		if opts.Driver == "" {
+			return ":memory:", nil
+		}
+		return "", fmt.Errorf("no dsn configured for database %q", optionsName(name))
+	}
+	return maskDSN(opts.DSN), nil
+}
+
 // Create 是一个用于创建数据库连接的方法。
 // 它接受一个数据库名称作为参数，并根据该名称获取数据库配置。
 // 如果没有指定数据库驱动和DSN，则使用默认的SQLite数据库和内存存储。
@@ -73,10 +252,13 @@ func Create(name string) (*gorm.DB, error) {
 		opts.DSN = ":memory:"
 	}
 
+	// 写入字符集、排序规则和会话时区
+	opts.DSN = connDSN(opts.Driver, opts.DSN, opts)
+
 	// 输出调试信息
-	slog.Debug("[sql] open", "driver", opts.Driver, "dsn", opts.DSN, "debug", opts.Debug)
+	slog.Debug("[sql] open", "driver", opts.Driver, "dsn", maskDSN(opts.DSN), "debug", opts.Debug)
 	// 使用获取的配置打开数据库连接
-	d, err := Open(opts.Driver, opts.DSN)
+	d, err := Open(opts.Driver, opts.DSN, &gorm.Config{DisableAutomaticPing: opts.DisableAutomaticPing})
 	if err != nil {
 		// 如果发生错误，返回nil和错误信息
 		return nil, err
@@ -85,6 +267,228 @@ func Create(name string) (*gorm.DB, error) {
 	if opts.Debug {
 		d.Config.Logger = logger.Default.LogMode(logger.Info)
 	}
+	// 设置连接池参数，零值保持驱动的默认值
+	if opts.MaxOpenConns > 0 || opts.MaxIdleConns > 0 || opts.ConnMaxLifetime > 0 || opts.ConnMaxIdleTime > 0 {
+		sqlDB, err := d.DB()
+		if err != nil {
+			return nil, err
+		}
+		applyPool(sqlDB, opts)
+	}
+	// 打开只读副本并注册读写分离插件
+	if len(opts.Replicas) > 0 {
+		r, err := replicaResolver(opts)
+		if err == nil {
+			err = d.Use(r)
+		}
+		if err != nil {
+			_ = closeDB(d)
+			return nil, fmt.Errorf("open replicas: %w", err)
+		}
+	}
+	// 注册默认查询范围的回调
+	if err = registerDefaultScopes(d, optionsName(name)); err != nil {
+		_ = closeDB(d)
+		return nil, fmt.Errorf("register default scopes: %w", err)
+	}
+	// 调用自定义的初始化函数
+	if opts.AfterOpen != nil {
+		if err = opts.AfterOpen(d); err != nil {
+			_ = closeDB(d)
+			return nil, fmt.Errorf("after open: %w", err)
+		}
+	}
 	// 返回数据库连接和nil，表示成功
 	return d, nil
 }
+
+// SetDefaultScopes 设置指定连接的默认查询范围。
+// 通过 Get、Default 等获取的该名称连接，在执行查询、更新、删除时都会自动应用这些查询范围，
+// 适合租户隔离、软删除等需要统一附加的过滤条件。插入语句不受影响。
+// 再次调用会替换之前的设置，scopes 为空时清除该连接的默认查询范围。
+// 可以在连接创建之前或之后调用，设置会在下一次执行语句时生效。
+//
+// 单个语句可以通过 WithoutDefaultScopes 跳过默认查询范围。
+//
+// 注意：默认查询范围产生的条件同样会满足 gorm 对 UPDATE/DELETE 必须带有条件的检查。
+//
+// 参数:
+//
+//	name - 连接名称，为空时表示默认连接。
+//	scopes - 默认应用的查询范围。
+func SetDefaultScopes(name string, scopes ...Scope) {
+	name = optionsName(name)
+	if len(scopes) == 0 {
+		defaultScopes.Delete(name)
+		return
+	}
+	defaultScopes.Store(name, slices.Clone(scopes))
+}
+
+// preloadCtxKey 标记预加载关联产生的查询，默认查询范围只作用于主查询。
+type preloadCtxKey struct{}
+
+// registerDefaultScopes 在 d 上注册应用默认查询范围的回调。
+// 查询范围返回新的实例（例如调用了 Session）时，它的查询子句和错误会合并回当前语句。
+// 预加载关联时 gorm 另行发起的查询通过上下文标记识别，不应用默认查询范围。
+func registerDefaultScopes(d *gorm.DB, name string) error {
+	apply := func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+		if _, skip := db.Statement.Settings.Load(skipDefaultScopesKey); skip {
+			return
+		}
+		if ctx := db.Statement.Context; ctx != nil && ctx.Value(preloadCtxKey{}) != nil {
+			return
+		}
+		if v, ok := defaultScopes.Load(name); ok {
+			for _, scope := range v.([]Scope) {
+				r := scope(db)
+				if r == nil || r == db {
+					continue
+				}
+				if r.Statement != db.Statement {
+					db.Statement.Clauses = r.Statement.Clauses
+				}
+				if r.Error != nil {
+					_ = db.AddError(r.Error)
+					return
+				}
+			}
+		}
+	}
+	// 预加载的查询继承主查询的上下文，预加载前加上标记（值为原来的上下文），完成后恢复。
+	markPreload := func(db *gorm.DB) {
+		if len(db.Statement.Preloads) > 0 {
+			ctx := db.Statement.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			db.Statement.Context = context.WithValue(ctx, preloadCtxKey{}, ctx)
+		}
+	}
+	unmarkPreload := func(db *gorm.DB) {
+		if ctx := db.Statement.Context; ctx != nil && len(db.Statement.Preloads) > 0 {
+			if orig, ok := ctx.Value(preloadCtxKey{}).(context.Context); ok {
+				db.Statement.Context = orig
+			}
+		}
+	}
+
+	cb := d.Callback()
+	return errors.Join(
+		cb.Query().Before("gorm:query").Register("gormx:default_scopes", apply),
+		cb.Query().After("gorm:query").Before("gorm:preload").Register("gormx:mark_preload", markPreload),
+		cb.Query().After("gorm:preload").Register("gormx:unmark_preload", unmarkPreload),
+		cb.Row().Before("gorm:row").Register("gormx:default_scopes", apply),
+		cb.Update().Before("gorm:update").Register("gormx:default_scopes", apply),
+		cb.Delete().Before("gorm:delete").Register("gormx:default_scopes", apply),
+	)
+}
+
+// ForEach 遍历当前已缓存的所有数据库连接，按名称排序依次调用 fn。
+// 遍历基于缓存的快照进行，fn 中可以安全地调用 Get 等函数。
+// 所有 fn 返回的错误会被合并后返回，单个连接出错不会中断遍历。
+//
+// 参数:
+//
+//	fn - 对每个连接执行的函数，name 为连接名称，db 为对应的连接。
+func ForEach(fn func(name string, db *gorm.DB) error) error {
+	snapshot := conns.Snapshot()
+
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := fn(name, snapshot[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭名称为 name 的已缓存连接并将其移出缓存，之后再次获取该名称时会重新创建连接。
+// 连接尚未创建或已关闭时直接返回 nil。
+// 关闭后仍持有旧 *gorm.DB 的调用方执行语句会得到错误，而不会 panic。
+//
+// 参数:
+//
+//	name - 连接名称，为空时表示默认连接。
+func Close(name string) error {
+	d, ok := conns.Evict(name)
+	if !ok {
+		return nil
+	}
+	return closeDB(d)
+}
+
+// Reload 按当前配置重新创建名称为 name 的连接并替换缓存，返回新的连接，
+// 适用于运行时配置变化（如数据库凭据轮换）后重建连接。
+// 新连接创建成功后旧连接会被关闭；创建失败时保留旧连接并返回错误。
+// 与 Get 并发调用是安全的：替换之前 Get 返回旧连接，之后返回新连接。
+//
+// 参数:
+//
+//	name - 连接名称，为空时表示默认连接。
+func Reload(name string) (*gorm.DB, error) {
+	return conns.Renew(name, func(old *gorm.DB) {
+		if err := closeDB(old); err != nil {
+			slog.Warn("[sql] close replaced connection", "name", optionsName(name), "err", err)
+		}
+	})
+}
+
+// CloseAll 关闭全部已缓存的连接并清空缓存，适合在程序退出或测试结束时调用。
+// 所有关闭时的错误会被合并后返回，单个连接出错不会中断其余连接的关闭。
+func CloseAll() error {
+	var errs []error
+	for name, d := range conns.EvictAll() {
+		if err := closeDB(d); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeDB 关闭 d 底层的 *sql.DB，以及通过 Options.Replicas 打开的副本连接。
+func closeDB(d *gorm.DB) error {
+	var errs []error
+	errs = append(errs, closeReplicas(d))
+	sqlDB, err := d.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	return errors.Join(append(errs, err)...)
+}
+
+// connDSN 返回按 opts 写入连接参数后的 dsn：mysql 连接写入字符集和排序规则，
+// 支持的方言写入会话时区（规则见 Options.Timezone）。主库和只读副本都通过它处理 DSN。
+func connDSN(driver, dsn string, opts Options) string {
+	if opts.Charset != "" || opts.Collation != "" {
+		if dialect, ok := lookupDriver(driver); ok && dialect(dsn).Name() == "mysql" {
+			dsn = dsnWithParams(dsn, "charset", opts.Charset, "collation", opts.Collation)
+		}
+	}
+	return dsnWithTimezone(driver, dsn, opts.Timezone)
+}
+
+// applyPool 把 opts 中的连接池参数应用到 sqlDB，零值保持驱动的默认值。
+func applyPool(sqlDB *sql.DB, opts Options) {
+	if opts.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(jitter(opts.ConnMaxLifetime, opts.ConnMaxLifetimeJitter))
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+}
@@ -0,0 +1,183 @@
+package gormx
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultPageSize 是未指定每页大小时使用的默认值。
+const defaultPageSize = 1000
+
+// pageClamp 规范化页码和每页大小，Paging 与 PageMeta 等共用这一套规则，使分页元数据与实际执行的查询一致。
+// 页码小于 1 时视为第 1 页；每页大小为 0 时使用 def，小于 0 时保持原值，表示不限制（不输出 LIMIT）。
+func pageClamp(page, size, def int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if size == 0 {
+		size = def
+	}
+	return page, size
+}
+
+// PageMeta 根据已知的总记录数计算分页元数据，页码和每页大小的规范化规则与 Paging 相同：
+// 页码小于 1 视为第 1 页，每页大小为 0 时使用默认值 1000，小于 0 时不分页，所有记录都在第 1 页。
+//
+// 参数:
+//
+//	total - 总记录数，小于等于 0 时总页数为 0。
+//	page - 当前页码。
+//	size - 每页大小。
+//
+// 返回值:
+//
+//	totalPages - 总页数。
+//	hasNext - 当前页之后是否还有数据。
+//	hasPrev - 当前页之前是否还有数据。
+func PageMeta(total int64, page, size int) (totalPages int, hasNext, hasPrev bool) {
+	page, size = pageClamp(page, size, defaultPageSize)
+	switch {
+	case total <= 0:
+	case size < 0:
+		totalPages = 1
+	default:
+		totalPages = int((total + int64(size) - 1) / int64(size))
+	}
+	return totalPages, page < totalPages, page > 1
+}
+
+// countDistinct 是统计分页总数时追加在最后的范围。
+// 查询带有 DISTINCT 或 JOIN 时，一对多的连接会使同一条主记录出现多次，
+// 此时改为统计主键的去重数量，避免总数和总页数虚高；
+// DISTINCT 只选择了单个列时统计该列的去重数量，选择了多个列、表达式或别名时
+// 改为 SELECT COUNT(*) FROM (<原查询>) 统计去重后的行数。带 GROUP BY 或模型没有主键时保持原样。
+func countDistinct(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+	if _, ok := stmt.Clauses["GROUP BY"]; ok {
+		return db
+	}
+	if !stmt.Distinct && len(stmt.Joins) == 0 {
+		from, _ := stmt.Clauses["FROM"].Expression.(clause.From)
+		if len(from.Joins) == 0 {
+			return db
+		}
+	}
+
+	var col clause.Column
+	switch {
+	case stmt.Distinct && len(stmt.Selects) == 1 && !strings.ContainsAny(stmt.Selects[0], "*,() "):
+		col = clause.Column{Name: stmt.Selects[0]}
+	case stmt.Distinct && len(stmt.Selects) > 0:
+		return countSubquery(db)
+	case stmt.Parse(stmt.Model) == nil && stmt.Schema.PrioritizedPrimaryField != nil:
+		col = clause.Column{Table: clause.CurrentTable, Name: stmt.Schema.PrioritizedPrimaryField.DBName}
+	default:
+		return db
+	}
+
+	stmt.AddClause(clause.Select{Expression: clause.Expr{SQL: "COUNT(DISTINCT ?)", Vars: []any{col}}})
+	return db
+}
+
+// countSubquery 把正在执行的统计语句改写为 SELECT COUNT(*) FROM (<原查询>) gormx_count。
+// 原查询保留全部条件、连接和 DISTINCT 选择的列，去除统计时无意义的排序和分页；
+// 外层语句只从子查询中计数，不再附加软删除和默认查询范围。
+func countSubquery(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+
+	// 指定 Context 使 Session 立即复制语句，之后对 stmt 的修改不会影响子查询。
+	sub := db.Session(&gorm.Session{Context: stmt.Context})
+	for _, name := range []string{"SELECT", "ORDER BY", "LIMIT"} {
+		delete(sub.Statement.Clauses, name)
+	}
+	sub.Statement.Preloads = nil
+
+	stmt.Clauses = map[string]clause.Clause{}
+	stmt.AddClause(clause.Select{Expression: clause.Expr{SQL: "COUNT(*)"}})
+	stmt.Joins, stmt.Selects, stmt.Omits, stmt.Distinct, stmt.Preloads = nil, nil, nil, false, nil
+	stmt.Unscoped = true
+	stmt.Settings.Store(skipDefaultScopesKey, true)
+	stmt.Table = "gormx_count"
+	stmt.TableExpr = &clause.Expr{SQL: "(?) AS gormx_count", Vars: []any{sub}}
+	return db
+}
+
+// Page 是分页查询的结果。
+type Page[T any] struct {
+	Items      []T   `json:"items"`       // 当前页的记录。
+	Total      int64 `json:"total"`       // 符合条件的总记录数。
+	Page       int   `json:"page"`        // 当前页码，从 1 开始。
+	Size       int   `json:"size"`        // 每页大小。
+	TotalPages int   `json:"total_pages"` // 总页数。
+}
+
+// List 查询模型 T 的记录，根据是否传入分页参数返回分页结果或全部记录。
+//
+// 当 page 和 size 都小于等于 0 时，不进行分页，返回全部符合条件的记录，
+// 此时 Total 为记录数，结果视为只有一页；否则先统计总数，再按 PageMeta 的规则规范化页码和每页大小后查询当前页。
+// scopes 同时作用于统计和查询，因此只应包含过滤条件、排序等不影响分页的范围；
+// 包含 DISTINCT 或 JOIN 时总数按主键去重统计。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	page - 页码。
+//	size - 每页大小。
+//	scopes - 查询范围。
+func List[T any](db *gorm.DB, page, size int, scopes ...Scope) (out Page[T], err error) {
+	fs := funcs(scopes)
+	db = db.Session(&gorm.Session{})
+
+	if page <= 0 && size <= 0 {
+		if err = db.Model(new(T)).Scopes(fs...).Find(&out.Items).Error; err != nil {
+			return
+		}
+		out.Total, out.Page, out.Size = int64(len(out.Items)), 1, len(out.Items)
+		if out.Total > 0 {
+			out.TotalPages = 1
+		}
+		return
+	}
+
+	out.Page, out.Size = pageClamp(page, size, defaultPageSize)
+	if err = db.Model(new(T)).Scopes(fs...).Scopes(countDistinct).Count(&out.Total).Error; err != nil {
+		return
+	}
+	out.TotalPages, _, _ = PageMeta(out.Total, out.Page, out.Size)
+
+	err = db.Model(new(T)).Scopes(fs...).Scopes(Paging[int, int, int](out.Page, out.Size)).Find(&out.Items).Error
+	return
+}
+
+// PageQuery 查询 db 上模型 T 第 page 页的记录，同时返回满足条件的总记录数。
+// 总数在 db 的独立会话上统计，并去除 db 上已有的 LIMIT/OFFSET，因此不受分页影响，
+// 查询包含 DISTINCT 或 JOIN 时按主键去重统计；
+// 页码和每页大小的规范化规则与 PageMeta 一致。
+// 页码超出最后一页时不再执行查询，返回空切片和正确的总数。
+//
+// 参数:
+//
+//	db - 已附加过滤条件、排序等的数据库连接。
+//	page - 页码。
+//	size - 每页大小。
+//
+// 返回值:
+//
+//	items - 当前页的记录，没有记录时为空切片。
+//	total - 满足条件的总记录数。
+//	err - 查询失败时返回错误。
+func PageQuery[T any](db *gorm.DB, page, size int) (items []T, total int64, err error) {
+	page, size = pageClamp(page, size, defaultPageSize)
+	if err = db.Session(&gorm.Session{}).Model(new(T)).Scopes(withoutLimit, countDistinct).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	items = []T{}
+	if int64(page-1)*int64(size) >= total {
+		return items, total, nil
+	}
+	err = db.Session(&gorm.Session{}).Model(new(T)).Scopes(Paging[int, int, int](page, size)).Find(&items).Error
+	return items, total, err
+}
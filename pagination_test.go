@@ -0,0 +1,183 @@
+package gormx
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestPageMeta(t *testing.T) {
+	tests := []struct {
+		total      int64
+		page, size int
+		pages      int
+		next, prev bool
+	}{
+		{0, 1, 10, 0, false, false},
+		{0, 3, 10, 0, false, true},
+		{10, 1, 10, 1, false, false},
+		{11, 1, 10, 2, true, false},
+		{25, 2, 10, 3, true, true},
+		{25, 3, 10, 3, false, true},
+		{25, 0, 10, 3, true, false},
+		{2500, 1, 0, 3, true, false},
+		{2500, 1, -5, 1, false, false},
+		{2500, 3, -5, 1, false, true},
+	}
+
+	for _, tt := range tests {
+		pages, next, prev := PageMeta(tt.total, tt.page, tt.size)
+		if pages != tt.pages || next != tt.next || prev != tt.prev {
+			t.Errorf("PageMeta(%d, %d, %d) = (%d, %v, %v), want (%d, %v, %v)",
+				tt.total, tt.page, tt.size, pages, next, prev, tt.pages, tt.next, tt.prev)
+		}
+	}
+}
+
+func TestPagingNegativeSize(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		page, size int
+		want       string // 为空表示不应输出 LIMIT
+	}{
+		{1, 0, "SELECT * FROM `zzs` LIMIT 1000"},
+		{2, 10, "SELECT * FROM `zzs` LIMIT 10 OFFSET 10"},
+		{1, -1, ""},
+		{3, -1, ""},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(Paging[int, int, int](tt.page, tt.size)).Find(&[]ZZ{}) })
+		switch {
+		case tt.want == "" && (strings.Contains(got, "LIMIT") || strings.Contains(got, "OFFSET")):
+			t.Errorf("Paging(%d, %d): unexpected LIMIT in %s", tt.page, tt.size, got)
+		case tt.want != "" && got != tt.want:
+			t.Errorf("Paging(%d, %d):\n got  %s\n want %s", tt.page, tt.size, got, tt.want)
+		}
+		if pages, next, _ := PageMeta(2500, tt.page, tt.size); tt.want == "" && (pages != 1 || next) {
+			t.Errorf("PageMeta(2500, %d, %d) = %d pages, next %v, want a single page", tt.page, tt.size, pages, next)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 25)
+
+	all, err := List[ZZ](db, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Items) != 25 || all.Total != 25 || all.Page != 1 || all.TotalPages != 1 {
+		t.Fatalf("unexpected unpaged result: total=%d items=%d page=%d pages=%d", all.Total, len(all.Items), all.Page, all.TotalPages)
+	}
+
+	odd := func(tx *gorm.DB) *gorm.DB { return tx.Where("id % 2 = 1") }
+	paged, err := List[ZZ](db, 2, 5, odd, OrderBy("-id", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paged.Total != 13 || paged.TotalPages != 3 || len(paged.Items) != 5 || paged.Items[0].ID != 15 {
+		t.Fatalf("unexpected paged result: %+v", paged)
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 25)
+
+	filtered := db.Where("id % 2 = 1").Order("id DESC").Limit(3)
+	items, total, err := PageQuery[ZZ](filtered, 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 13 || len(items) != 5 || items[0].ID != 15 {
+		t.Fatalf("total=%d items=%+v", total, items)
+	}
+
+	items, total, err = PageQuery[ZZ](filtered, 4, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 13 || items == nil || len(items) != 0 {
+		t.Fatalf("page beyond last: total=%d items=%+v", total, items)
+	}
+}
+
+type pageNote struct {
+	ID     int
+	PostID int
+	Note   string
+}
+
+type pageNotePost struct {
+	Pid int
+}
+
+func (pageNotePost) TableName() string { return "page_notes" }
+
+func TestPageCountDistinct(t *testing.T) {
+	db := newTestDB(t, &testPost{}, &testComment{})
+	db.Create(&[]testPost{
+		{ID: 1, Comments: []testComment{{ID: 1}, {ID: 2}, {ID: 3}}},
+		{ID: 2, Comments: []testComment{{ID: 4}, {ID: 5}}},
+		{ID: 3},
+	})
+
+	fanned := func(tx *gorm.DB) *gorm.DB {
+		return tx.Joins("JOIN test_comments ON test_comments.post_id = test_posts.id")
+	}
+	page, err := List[testPost](db, 1, 10, fanned, func(tx *gorm.DB) *gorm.DB { return tx.Distinct("test_posts.*") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || len(page.Items) != 2 {
+		t.Fatalf("List total=%d items=%d, want 2", page.Total, len(page.Items))
+	}
+
+	_, total, err := PageQuery[testPost](db.Scopes(fanned), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Fatalf("PageQuery total=%d, want 2", total)
+	}
+
+	_, total, err = PageQuery[testComment](db.Distinct("post_id"), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Fatalf("distinct column total=%d, want 2", total)
+	}
+
+	if err = db.AutoMigrate(&pageNote{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]pageNote{{PostID: 1, Note: "a"}, {PostID: 1, Note: "a"}, {PostID: 2, Note: "b"}, {PostID: 2, Note: "b"}})
+
+	items, total, err := PageQuery[pageNote](db.Distinct("post_id", "note"), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(items) != 2 {
+		t.Fatalf("multi-column distinct total=%d items=%d, want 2", total, len(items))
+	}
+
+	_, total, err = PageQuery[pageNotePost](db.Distinct("post_id AS pid"), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Fatalf("aliased distinct total=%d, want 2", total)
+	}
+
+	byNote := func(tx *gorm.DB) *gorm.DB { return tx.Distinct("post_id", "note").Where("note = ?", "b") }
+	notes, err := List[pageNote](db, 1, 10, byNote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes.Total != 1 || len(notes.Items) != 1 {
+		t.Fatalf("List multi-column distinct total=%d items=%d, want 1", notes.Total, len(notes.Items))
+	}
+}
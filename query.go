@@ -0,0 +1,337 @@
+package gormx
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApproxCount 返回模型对应表的记录数，在 postgres 上返回的是估算值。
+//
+// postgres 上读取 pg_class.reltuples，即最近一次 VACUUM/ANALYZE 统计的行数，
+// 速度很快但不精确，适合用于界面展示等不要求精确的场景；表尚未被统计时回退为精确计数。
+// 其他方言直接执行 COUNT(*) 返回精确值。
+//
+// 注意：估算值基于整张表，db 上附加的查询条件在 postgres 上不会生效。
+func ApproxCount(db *gorm.DB, model any) (int64, error) {
+	if dialectName(db) == "postgres" {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return 0, err
+		}
+
+		// to_regclass 按 SQL 标识符解析参数，未加引号的名称会转为小写，
+		// 因此先按方言加上引号，保留大小写以及 schema.table 中的 schema。
+		var estimate *float64
+		err := db.Session(&gorm.Session{NewDB: true}).
+			Raw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", stmt.Quote(stmt.Schema.Table)).
+			Scan(&estimate).Error
+		if err != nil {
+			return 0, err
+		}
+		if estimate != nil && *estimate >= 0 {
+			return int64(*estimate), nil
+		}
+	}
+
+	var count int64
+	err := db.Model(model).Count(&count).Error
+	return count, err
+}
+
+// ReplaceAssociation 把 record 的关联 field 替换为 assocs，
+// 等同于 db.Model(record).Association(field).Replace(assocs)，并在执行前校验关联是否存在。
+// 对于多对多关联，会同步更新连接表；assocs 为空时清空该关联。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	record - 拥有关联的记录，必须已有主键。
+//	field - 关联字段名称，例如 "Roles"。
+//	assocs - 新的关联记录集合。
+//
+// 返回值:
+//
+//	error - 关联不存在或执行失败时返回错误。
+func ReplaceAssociation[T any, A any](db *gorm.DB, record *T, field string, assocs []A) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(record); err != nil {
+		return err
+	}
+	if _, ok := stmt.Schema.Relationships.Relations[field]; !ok {
+		return fmt.Errorf("association %q not found on %s", field, stmt.Schema.Name)
+	}
+
+	association := db.Model(record).Association(field)
+	if len(assocs) == 0 {
+		return association.Clear()
+	}
+	return association.Replace(assocs)
+}
+
+// CountAndExists 在一次 COUNT 查询中同时得到满足条件的记录数和是否存在记录。
+// scopes 中的分页条件（LIMIT/OFFSET）会被忽略，排序由 gorm 在计数时自动去除，
+// 因此可以直接复用列表查询的查询范围。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	scopes - 过滤条件。
+//
+// 返回值:
+//
+//	count - 满足条件的记录数。
+//	exists - 是否存在满足条件的记录，即 count > 0。
+//	err - 查询失败时返回错误。
+func CountAndExists[T any](db *gorm.DB, scopes ...Scope) (count int64, exists bool, err error) {
+	err = db.Model(new(T)).Scopes(funcs(scopes)...).Scopes(withoutLimit).Count(&count).Error
+	return count, count > 0, err
+}
+
+// CountColumn 返回满足条件且 col 列不为 NULL 的记录数，即 `SELECT COUNT(col)`，
+// 与 COUNT(*) 统计全部行不同，可以用来统计“有值”的行数。
+// scopes 中的排序和分页条件会被忽略。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	col - 需要统计的列名，可以带表名。
+//	scopes - 过滤条件。
+//
+// 返回值:
+//
+//	int64 - col 不为 NULL 的记录数。
+//	error - 查询失败时返回错误。
+func CountColumn[T any](db *gorm.DB, col string, scopes ...Scope) (int64, error) {
+	var count int64
+	err := db.Model(new(T)).
+		Scopes(funcs(scopes)...).
+		Scopes(withoutLimit, withoutOrder).
+		Select("COUNT(?)", column(col)).
+		Scan(&count).Error
+	return count, err
+}
+
+// Project 查询 db 上的指定列并扫描到 []T 中，T 的字段按数据库列名匹配，
+// 适合只读取少量列组成轻量的读模型，而不必查询完整的模型。
+// 表由 db 通过 Model 或 Table 指定，T 只用于接收结果；列名会经过 column() 处理并由方言加上引号，
+// 支持 `name AS alias` 形式，columns 为空时查询全部列。没有记录时返回空切片。
+//
+// 参数:
+//
+//	db - 已指定表以及过滤条件、排序等的数据库连接。
+//	columns - 需要查询的列名。
+//
+// 返回值:
+//
+//	[]T - 查询结果。
+//	error - 查询失败时返回错误。
+func Project[T any](db *gorm.DB, columns ...string) ([]T, error) {
+	out := []T{}
+	if len(columns) > 0 {
+		vars := make([]any, len(columns))
+		for i, c := range columns {
+			vars[i] = column(c)
+		}
+		db = db.Select(strings.TrimSuffix(strings.Repeat("?,", len(vars)), ","), vars...)
+	}
+	err := db.Scan(&out).Error
+	return out, err
+}
+
+// withoutOrder 去除语句中的 ORDER BY 子句。
+func withoutOrder(db *gorm.DB) *gorm.DB {
+	delete(db.Statement.Clauses, "ORDER BY")
+	return db
+}
+
+// withoutLimit 去除语句中的 LIMIT/OFFSET 子句。
+func withoutLimit(db *gorm.DB) *gorm.DB {
+	delete(db.Statement.Clauses, "LIMIT")
+	return db
+}
+
+// UpdateByID 按主键更新一条记录的指定字段，返回是否有记录被更新。
+// id 为 nil 或零值时返回错误，避免误更新整张表。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	id - 主键值。
+//	values - 需要更新的列及其新值，键为列名或字段名。
+//
+// 返回值:
+//
+//	bool - 是否有记录被更新，主键不存在时为 false。
+//	error - 模型没有主键、id 为空或执行失败时返回错误。
+func UpdateByID[T any](db *gorm.DB, id any, values map[string]any) (bool, error) {
+	if id == nil || reflect.ValueOf(id).IsZero() {
+		return false, fmt.Errorf("update by id: empty primary key")
+	}
+
+	pk, err := primaryColumn[T](db)
+	if err != nil {
+		return false, err
+	}
+
+	result := db.Model(new(T)).Where(clause.Eq{Column: pk, Value: id}).Updates(values)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateChanged 比较同一条记录修改前后的两个值，只更新发生变化的列，适合实现 PATCH 接口。
+// 记录按 after 的主键定位；没有任何列变化时不执行更新，直接返回 0。
+// 主键和不可更新的字段不参与比较，更新通过 Updates 执行，会触发钩子并维护 UpdatedAt。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	before - 修改前的记录。
+//	after - 修改后的记录。
+//
+// 返回值:
+//
+//	int64 - 受影响的行数。
+//	error - 参数为 nil、主键为空、模型没有主键或执行失败时返回错误。
+func UpdateChanged[T any](db *gorm.DB, before, after *T) (int64, error) {
+	if before == nil || after == nil {
+		return 0, fmt.Errorf("update changed: nil record")
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(after); err != nil {
+		return 0, err
+	}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return 0, gorm.ErrPrimaryKeyRequired
+	}
+
+	ctx := db.Statement.Context
+	bv, av := reflect.ValueOf(before).Elem(), reflect.ValueOf(after).Elem()
+	id, zero := pk.ValueOf(ctx, av)
+	if zero {
+		return 0, fmt.Errorf("update changed: empty primary key")
+	}
+
+	values := map[string]any{}
+	for _, name := range stmt.Schema.DBNames {
+		f := stmt.Schema.FieldsByDBName[name]
+		if f.PrimaryKey || !f.Updatable {
+			continue
+		}
+		b, _ := f.ValueOf(ctx, bv)
+		a, _ := f.ValueOf(ctx, av)
+		if !reflect.DeepEqual(a, b) {
+			values[name] = a
+		}
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	pkc := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	result := db.Model(new(T)).Where(clause.Eq{Column: pkc, Value: id}).Updates(values)
+	return result.RowsAffected, result.Error
+}
+
+// BatchUpdate 在一条 UPDATE 语句中把多条记录的 col 列更新为各自不同的值，
+// 与 SortExec 类似，通过 CaseExpr 生成 `SET col = CASE key WHEN ... END WHERE key IN (...)`，
+// 适用于任意列而不仅是排序列。与 UpdateColumn 一样不会触发钩子，也不会更新 UpdatedAt。
+//
+// 参数:
+//
+//	tx - 数据库连接，通常通过 tx.Model(&T{}) 或 tx.Table(name) 指定表，为 nil 时使用默认连接。
+//	col - 需要更新的列名。
+//	values - 键为 keyColumn 的值，值为对应记录的新值，为空时不执行任何操作。
+//	keyColumn - 标识记录的列名，为空时使用模型主键，没有模型时为 "id"。
+//
+// 返回值:
+//
+//	int64 - 更新的行数。
+//	error - 执行失败时返回错误。
+func BatchUpdate[K cmp.Ordered, V any](tx *gorm.DB, col string, values map[K]V, keyColumn string) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	if tx == nil {
+		tx = Default()
+	}
+
+	c, kc := column(col), column(keyColumn)
+	if kc.Name == "" {
+		if tx.Statement.Model != nil {
+			kc.Name = clause.PrimaryKey
+		} else {
+			kc.Name = "id"
+		}
+	}
+
+	value, keys := caseExpr(kc, values, c)
+	result := tx.Where("? IN ?", kc, keys).UpdateColumn(c.Name, value)
+	return result.RowsAffected, result.Error
+}
+
+// primaryColumn 解析模型 T 的主键列。
+func primaryColumn[T any](db *gorm.DB) (clause.Column, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return clause.Column{}, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return clause.Column{}, gorm.ErrPrimaryKeyRequired
+	}
+	return clause.Column{Table: clause.CurrentTable, Name: stmt.Schema.PrioritizedPrimaryField.DBName}, nil
+}
+
+// deleteChunkSize 是 DeleteByIDs 默认每批删除的主键数量。
+const deleteChunkSize = 500
+
+// DeleteByIDs 按主键批量删除记录，ids 会被分成每批 chunkSize 个，
+// 每批执行一条 DELETE ... WHERE pk IN (...)，以避免超过数据库的参数数量限制。
+// 全部批次在一个事务中执行，任意一批失败时整体回滚。
+// 模型带有软删除字段时执行的是软删除。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	ids - 需要删除的主键值，为空时不执行任何操作。
+//	chunkSize - 每批删除的数量，小于等于 0 时为 500。
+//
+// 返回值:
+//
+//	int64 - 删除的行数之和。
+//	error - 模型没有主键或执行失败时返回错误。
+func DeleteByIDs[T any](db *gorm.DB, ids []any, chunkSize int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if chunkSize <= 0 {
+		chunkSize = deleteChunkSize
+	}
+
+	pk, err := primaryColumn[T](db)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for chunk := range slices.Chunk(ids, chunkSize) {
+			result := tx.Where(clause.IN{Column: pk, Values: chunk}).Delete(new(T))
+			if result.Error != nil {
+				return result.Error
+			}
+			rows += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
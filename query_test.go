@@ -0,0 +1,356 @@
+package gormx
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// postgresTestDB 返回用于测试的 postgres 连接，未设置 GORMX_TEST_POSTGRES_DSN 时跳过测试。
+func postgresTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("GORMX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GORMX_TEST_POSTGRES_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn))
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	if err = db.Migrator().DropTable(models...); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApproxCount(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 12)
+
+	count, err := ApproxCount(db, &ZZ{})
+	if err != nil || count != 12 {
+		t.Fatalf("count = %d, err = %v, want 12", count, err)
+	}
+}
+
+type approxMixed struct{ ID int }
+
+func (approxMixed) TableName() string { return "Reports" }
+
+type approxSchema struct{ ID int }
+
+func (approxSchema) TableName() string { return "audit.Logs" }
+
+func TestApproxCountQuote(t *testing.T) {
+	db := postgresDryRun(t)
+	var names []any
+	err := db.Callback().Row().After("gorm:row").Register("test:capture_regclass", func(tx *gorm.DB) {
+		if strings.Contains(tx.Statement.SQL.String(), "to_regclass") {
+			names = append(names, tx.Statement.Vars...)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, model := range []any{&approxMixed{}, &approxSchema{}} {
+		// 只生成 SQL 的连接上 Scan 返回 ErrDryRunModeUnsupported，参数仍会被记录
+		if _, err := ApproxCount(db, model); err != nil && !errors.Is(err, gorm.ErrDryRunModeUnsupported) {
+			t.Fatal(err)
+		}
+	}
+	if want := []any{`"Reports"`, `"audit"."Logs"`}; !slices.Equal(names, want) {
+		t.Fatalf("to_regclass arguments = %v, want %v", names, want)
+	}
+}
+
+func TestApproxCountPostgres(t *testing.T) {
+	db := postgresTestDB(t, &ZZ{})
+	seedSort(t, db, 12)
+
+	// 未统计时回退为精确计数。
+	if count, err := ApproxCount(db, &ZZ{}); err != nil || count != 12 {
+		t.Fatalf("count = %d, err = %v, want 12", count, err)
+	}
+
+	if err := db.Exec("ANALYZE zzs").Error; err != nil {
+		t.Fatal(err)
+	}
+	if count, err := ApproxCount(db, &ZZ{}); err != nil || count != 12 {
+		t.Fatalf("count = %d, err = %v, want 12", count, err)
+	}
+}
+
+type jsonDoc struct {
+	ID   int
+	Data string `gorm:"type:jsonb"`
+}
+
+func TestJSONEqualsPostgres(t *testing.T) {
+	db := postgresTestDB(t, &jsonDoc{})
+	db.Create(&[]jsonDoc{{ID: 1, Data: `{"n": 1, "ok": true}`}, {ID: 2, Data: `{"n": 2, "ok": false}`}})
+
+	for _, tt := range []struct {
+		key   string
+		value any
+	}{{"n", 2}, {"ok", false}} {
+		var ids []int
+		if err := db.Model(&jsonDoc{}).Scopes(JSONEquals("data", tt.key, tt.value)).Pluck("id", &ids).Error; err != nil {
+			t.Fatalf("%s = %v: %v", tt.key, tt.value, err)
+		}
+		if !slices.Equal(ids, []int{2}) {
+			t.Errorf("%s = %v: got %v, want [2]", tt.key, tt.value, ids)
+		}
+	}
+}
+
+type testMember struct {
+	ID   int
+	Name string
+	Tags []testTag `gorm:"many2many:test_member_tags"`
+}
+
+type testTag struct {
+	ID   int
+	Name string
+}
+
+func TestReplaceAssociation(t *testing.T) {
+	db := newTestDB(t, &testMember{}, &testTag{})
+
+	member := testMember{ID: 1, Name: "m", Tags: []testTag{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}}
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tagIDs := func() (ids []int) {
+		if err := db.Table("test_member_tags").Where("test_member_id = ?", 1).Order("test_tag_id").Pluck("test_tag_id", &ids).Error; err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if err := ReplaceAssociation(db, &member, "Tags", []testTag{{ID: 2, Name: "b"}, {ID: 3, Name: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tagIDs(); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("join table = %v, want [2 3]", got)
+	}
+
+	if err := ReplaceAssociation(db, &member, "Tags", []testTag{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tagIDs(); len(got) != 0 {
+		t.Fatalf("join table = %v, want empty", got)
+	}
+
+	if err := ReplaceAssociation(db, &member, "Missing", []testTag{}); err == nil {
+		t.Fatal("expected error for unknown association")
+	}
+}
+
+func TestCountAndExists(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 10)
+
+	count, exists, err := CountAndExists[ZZ](db, func(tx *gorm.DB) *gorm.DB { return tx.Where("id > ?", 6) }, Paging[int, int, int](1, 2))
+	if err != nil || count != 4 || !exists {
+		t.Fatalf("count = %d, exists = %v, err = %v, want 4 true", count, exists, err)
+	}
+
+	count, exists, err = CountAndExists[ZZ](db, func(tx *gorm.DB) *gorm.DB { return tx.Where("id > ?", 100) })
+	if err != nil || count != 0 || exists {
+		t.Fatalf("count = %d, exists = %v, err = %v, want 0 false", count, exists, err)
+	}
+}
+
+func TestDeleteByIDs(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 20)
+
+	ids := make([]any, 0, 12)
+	for i := 1; i <= 12; i++ {
+		ids = append(ids, i)
+	}
+	ids = append(ids, 100) // 不存在的记录
+
+	n, err := DeleteByIDs[ZZ](db, ids, 5)
+	if err != nil || n != 12 {
+		t.Fatalf("n = %d, err = %v, want 12", n, err)
+	}
+	var count int64
+	db.Model(&ZZ{}).Count(&count)
+	if count != 8 {
+		t.Fatalf("remaining = %d, want 8", count)
+	}
+
+	if n, err = DeleteByIDs[ZZ](db, nil, 0); err != nil || n != 0 {
+		t.Fatalf("empty: n = %d, err = %v", n, err)
+	}
+
+	// 第二批失败时，第一批的删除也应回滚。
+	calls := 0
+	err = db.Callback().Delete().Before("gorm:delete").Register("test:fail_second", func(tx *gorm.DB) {
+		if calls++; calls == 2 {
+			_ = tx.AddError(errors.New("boom"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DeleteByIDs[ZZ](db, []any{13, 14, 15, 16}, 2); err == nil {
+		t.Fatal("expected error")
+	}
+	db.Model(&ZZ{}).Count(&count)
+	if count != 8 {
+		t.Fatalf("remaining after rollback = %d, want 8", count)
+	}
+}
+
+func TestUpdateByID(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 3)
+
+	ok, err := UpdateByID[ZZ](db, 2, map[string]any{"sort": 20})
+	if err != nil || !ok {
+		t.Fatalf("existing: ok = %v, err = %v", ok, err)
+	}
+	var row ZZ
+	db.First(&row, 2)
+	if row.Sort != 20 {
+		t.Fatalf("sort = %d, want 20", row.Sort)
+	}
+
+	if ok, err = UpdateByID[ZZ](db, 100, map[string]any{"sort": 1}); err != nil || ok {
+		t.Fatalf("missing: ok = %v, err = %v", ok, err)
+	}
+
+	for _, id := range []any{nil, 0, ""} {
+		if _, err = UpdateByID[ZZ](db, id, map[string]any{"sort": 1}); err == nil {
+			t.Fatalf("expected error for id %#v", id)
+		}
+	}
+}
+
+func TestUpdateChanged(t *testing.T) {
+	db := newTestDB(t, &testOrder{})
+	old := testOrder{ID: 1, UserID: 7, Amount: 10, Note: "a"}
+	db.Create(&old)
+
+	var sqls []string
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture_sql", func(tx *gorm.DB) {
+		sqls = append(sqls, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cur := old
+	cur.Note = "b"
+	n, err := UpdateChanged(db, &old, &cur)
+	if err != nil || n != 1 {
+		t.Fatalf("n = %d, err = %v", n, err)
+	}
+	if want := "UPDATE `test_orders` SET `note`=? WHERE `test_orders`.`id` = ?"; len(sqls) != 1 || sqls[0] != want {
+		t.Fatalf("got %q, want %s", sqls, want)
+	}
+	var row testOrder
+	db.First(&row, 1)
+	if row != cur {
+		t.Fatalf("row = %+v, want %+v", row, cur)
+	}
+
+	if n, err = UpdateChanged(db, &cur, &cur); err != nil || n != 0 || len(sqls) != 1 {
+		t.Fatalf("unchanged: n = %d, err = %v, sqls = %q", n, err, sqls)
+	}
+	if _, err = UpdateChanged(db, &testOrder{}, &testOrder{Note: "x"}); err == nil {
+		t.Fatal("expected error for empty primary key")
+	}
+}
+
+func TestBatchUpdate(t *testing.T) {
+	db := newTestDB(t, &testOrder{})
+	db.Create(&[]testOrder{{ID: 1, Note: "a"}, {ID: 2, Note: "b"}, {ID: 3, Note: "c"}, {ID: 4, Note: "d"}})
+
+	updates := 0
+	if err := db.Callback().Update().Before("gorm:update").Register("test:count", func(*gorm.DB) { updates++ }); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := BatchUpdate(db.Model(&testOrder{}), "note", map[int]string{1: "x", 2: "y", 4: "z"}, "")
+	if err != nil || n != 3 {
+		t.Fatalf("n = %d, err = %v, want 3", n, err)
+	}
+	if updates != 1 {
+		t.Fatalf("updates = %d, want a single statement", updates)
+	}
+
+	var notes []string
+	db.Model(&testOrder{}).Order("id").Pluck("note", &notes)
+	if !slices.Equal(notes, []string{"x", "y", "c", "z"}) {
+		t.Fatalf("notes = %v", notes)
+	}
+
+	if n, err = BatchUpdate(db.Table("test_orders"), "amount", map[int]int{3: 30}, "id"); err != nil || n != 1 {
+		t.Fatalf("table: n = %d, err = %v", n, err)
+	}
+}
+
+func TestCountColumn(t *testing.T) {
+	type contact struct {
+		ID    int
+		Email *string
+	}
+	db := newTestDB(t, &contact{})
+	email := "a@example.com"
+	db.Create(&[]contact{{Email: &email}, {}, {Email: &email}, {}})
+
+	n, err := CountColumn[contact](db, "email")
+	if err != nil || n != 2 {
+		t.Fatalf("n = %d, err = %v, want 2", n, err)
+	}
+
+	n, err = CountColumn[contact](db, "contacts.email", func(tx *gorm.DB) *gorm.DB { return tx.Where("id > ?", 1) }, OrderBy("-id", ""), Paging(1, 1, 0))
+	if err != nil || n != 1 {
+		t.Fatalf("filtered: n = %d, err = %v, want 1", n, err)
+	}
+}
+
+func TestProject(t *testing.T) {
+	db := newTestDB(t, &testOrder{})
+	db.Create(&[]testOrder{{UserID: 1, Amount: 10, Note: "a"}, {UserID: 2, Amount: 20, Note: "b"}})
+
+	type orderDTO struct {
+		UserID int
+		Label  string
+	}
+	var sqls []string
+	if err := db.Callback().Row().After("gorm:row").Register("test:capture_sql", func(tx *gorm.DB) {
+		sqls = append(sqls, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Project[orderDTO](db.Model(&testOrder{}).Order("id"), "user_id", "note AS label")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []orderDTO{{1, "a"}, {2, "b"}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if want := "SELECT `test_orders`.`user_id`,`test_orders`.`note` AS `label` FROM `test_orders` ORDER BY id"; len(sqls) != 1 || sqls[0] != want {
+		t.Fatalf("got %q, want %s", sqls, want)
+	}
+
+	if got, err = Project[orderDTO](db.Model(&testOrder{}).Where("id < 0"), "user_id"); err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("empty: got %+v, err = %v", got, err)
+	}
+}
@@ -0,0 +1,65 @@
+package gormx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// resolverPluginName 是 dbresolver 插件注册的名称。
+const resolverPluginName = "gorm:db_resolver"
+
+// replicaResolver 按 opts 创建把读操作路由到只读副本的 dbresolver 插件，路由规则即 dbresolver 的默认行为：
+//
+//	查询（Find、First、Scan、Row 等）随机发送到一个副本；
+//	写操作（Create、Update、Delete、Exec）以及事务中的全部语句发送到主库；
+//	带有锁定子句（FOR UPDATE 等）或 dbresolver.Write 标记的查询发送到主库；
+//	原生 SQL 只有以 SELECT 开头且不以 FOR UPDATE 结尾时才发送到副本。
+//
+// 副本使用 opts.ReplicaDriver 指定的驱动，为空时与主库使用相同的驱动；
+// 连接池参数与主库相同，DSN 与主库一样经过 connDSN 写入字符集、排序规则和会话时区。
+func replicaResolver(opts Options) (*dbresolver.DBResolver, error) {
+	driver := opts.ReplicaDriver
+	if driver == "" {
+		driver = opts.Driver
+	}
+	dialect, ok := lookupDriver(driver)
+	if !ok {
+		return nil, fmt.Errorf("unknown replica driver: %s", driver)
+	}
+
+	dialectors := make([]gorm.Dialector, len(opts.Replicas))
+	for i, dsn := range opts.Replicas {
+		dialectors[i] = dialect(connDSN(driver, dsn, opts))
+	}
+
+	r := dbresolver.Register(dbresolver.Config{Replicas: dialectors})
+	_ = r.Call(func(pool gorm.ConnPool) error {
+		if sqlDB, ok := pool.(*sql.DB); ok {
+			applyPool(sqlDB, opts)
+		}
+		return nil
+	})
+	return r, nil
+}
+
+// closeReplicas 关闭 d 上 dbresolver 插件打开的连接，d 自身的连接不在此关闭。
+func closeReplicas(d *gorm.DB) error {
+	r, ok := d.Plugins[resolverPluginName].(*dbresolver.DBResolver)
+	if !ok {
+		return nil
+	}
+	primary, _ := d.DB()
+
+	var errs []error
+	_ = r.Call(func(pool gorm.ConnPool) error {
+		if sqlDB, ok := pool.(*sql.DB); ok && sqlDB != primary {
+			errs = append(errs, sqlDB.Close())
+		}
+		return nil
+	})
+	return errors.Join(errs...)
+}
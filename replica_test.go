@@ -0,0 +1,135 @@
+package gormx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+func TestReplicas(t *testing.T) {
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	for _, dsn := range []string{primary, replica} {
+		db, err := Open("sqlite", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = db.AutoMigrate(&testOrder{}); err != nil {
+			t.Fatal(err)
+		}
+		db.Create(&testOrder{Note: dsn})
+		_ = closeDB(db)
+	}
+
+	setOptions(t, func(name string) Options {
+		return Options{Driver: "sqlite", DSN: primary, Replicas: []string{replica}}
+	})
+	db, err := Create("replicas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = closeDB(db) })
+
+	note := func(tx *gorm.DB) string {
+		var o testOrder
+		if err := tx.First(&o).Error; err != nil {
+			t.Fatal(err)
+		}
+		return o.Note
+	}
+
+	if got := note(db); got != replica {
+		t.Errorf("read: got %s, want replica", got)
+	}
+	if got := note(db.Scopes(ForUpdate())); got != primary {
+		t.Errorf("locking read: got %s, want primary", got)
+	}
+	if got := note(db.Clauses(dbresolver.Write)); got != primary {
+		t.Errorf("write marker: got %s, want primary", got)
+	}
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		if got := note(tx); got != primary {
+			t.Errorf("transaction: got %s, want primary", got)
+		}
+		return nil
+	})
+
+	if err = db.Create(&testOrder{Note: "new"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	db.Model(&testOrder{}).Clauses(dbresolver.Write).Count(&n)
+	if n != 2 {
+		t.Errorf("write should go to primary, primary rows = %d", n)
+	}
+
+	// 原生 SQL 只有普通的 SELECT 才发送到副本。
+	var raw string
+	if err = db.Raw("SELECT note FROM test_orders WHERE id = 1").Scan(&raw).Error; err != nil || raw != replica {
+		t.Errorf("raw select: got %s (%v), want replica", raw, err)
+	}
+	var id int
+	if err = db.Raw("UPDATE test_orders SET note = 'written' WHERE id = 1 RETURNING id").Scan(&id).Error; err != nil || id != 1 {
+		t.Fatalf("raw update returning: id = %d, err = %v", id, err)
+	}
+	if got := note(db.Clauses(dbresolver.Write)); got != "written" {
+		t.Errorf("raw update returning should write primary, primary note = %s", got)
+	}
+	if got := note(db); got != replica {
+		t.Errorf("raw update returning should not touch replica, replica note = %s", got)
+	}
+	// sqlite 不支持 FOR UPDATE，因此记录路由选择的连接而不检查执行结果。
+	var pool gorm.ConnPool
+	_ = db.Callback().Row().After("gorm:db_resolver").Register("test:capture_pool", func(tx *gorm.DB) { pool = tx.Statement.ConnPool })
+	primaryDB, _ := db.DB()
+	var ids []int
+	db.Session(&gorm.Session{Logger: logger.Discard}).Raw("SELECT id FROM test_orders FOR UPDATE").Scan(&ids)
+	if pool != primaryDB {
+		t.Error("raw select for update should use primary")
+	}
+	db.Raw("SELECT id FROM test_orders").Scan(&ids)
+	if pool == primaryDB {
+		t.Error("raw select should use a replica")
+	}
+
+	setOptions(t, func(name string) Options {
+		return Options{Driver: "sqlite", DSN: primary, Replicas: []string{replica}, ReplicaDriver: "unknown"}
+	})
+	if _, err = Create("replicas_bad"); err == nil {
+		t.Fatal("expected error for unknown replica driver")
+	}
+}
+
+func TestReplicaDSN(t *testing.T) {
+	var dsns []string
+	RegisterDriver("replica_mysql", func(dsn string) gorm.Dialector {
+		dsns = append(dsns, dsn)
+		return mysql.New(mysql.Config{DSN: dsn})
+	})
+	t.Cleanup(func() { UnregisterDriver("replica_mysql") })
+
+	opts := Options{
+		Driver:    "replica_mysql",
+		DSN:       "root@tcp(primary)/db",
+		Replicas:  []string{"root@tcp(replica)/db"},
+		Charset:   "utf8mb4",
+		Collation: "utf8mb4_unicode_ci",
+		Timezone:  "UTC",
+	}
+	if _, err := replicaResolver(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "root@tcp(replica)/db?charset=utf8mb4&collation=utf8mb4_unicode_ci&time_zone=%27%2B00%3A00%27&loc=UTC"
+	if len(dsns) == 0 || dsns[len(dsns)-1] != want {
+		t.Fatalf("replica dsn = %v, want %s", dsns, want)
+	}
+	if got := connDSN(opts.Driver, opts.DSN, opts); got != strings.Replace(want, "replica", "primary", 1) {
+		t.Fatalf("primary dsn = %s, want the same parameters as the replica", got)
+	}
+}
@@ -1,10 +1,20 @@
 package gormx
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 // Scope 是一个定义了如何对数据库查询进行条件化修改的函数类型。
@@ -13,20 +23,185 @@ import (
 // 使用 Scope 可以动态地修改数据库查询，例如添加额外的条件、排序规则等。
 type Scope func(*gorm.DB) *gorm.DB
 
+// Bind1 将带一个额外参数的查询函数绑定参数后转换为 Scope。
+//
+// 例如仓储方法 func ByStatus(db *gorm.DB, status int) *gorm.DB，
+// 可以通过 Bind1(ByStatus, 1) 直接作为 Scope 使用，而无需编写闭包。
+func Bind1[A any](fn func(*gorm.DB, A) *gorm.DB, a A) Scope {
+	return func(db *gorm.DB) *gorm.DB { return fn(db, a) }
+}
+
+// Bind2 与 Bind1 类似，用于带两个额外参数的查询函数。
+func Bind2[A, B any](fn func(*gorm.DB, A, B) *gorm.DB, a A, b B) Scope {
+	return func(db *gorm.DB) *gorm.DB { return fn(db, a, b) }
+}
+
+// Bind3 与 Bind1 类似，用于带三个额外参数的查询函数。
+func Bind3[A, B, C any](fn func(*gorm.DB, A, B, C) *gorm.DB, a A, b B, c C) Scope {
+	return func(db *gorm.DB) *gorm.DB { return fn(db, a, b, c) }
+}
+
+// WhereIf 在 cond 为 true 时应用查询范围 s，否则不做任何修改。
+// 适合根据可选的查询参数组装条件，例如 WhereIf(q != "", Like("name", q))。
+func WhereIf(cond bool, s Scope) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if !cond || s == nil {
+			return db
+		}
+		return s(db)
+	}
+}
+
+// Scopes 把多个查询范围组合为一个，按顺序依次应用，nil 会被忽略。
+//
+//	filter := gormx.Scopes(
+//		gormx.WhereIf(q != "", gormx.Like("name", q)),
+//		gormx.WhereIf(status > 0, gormx.Bind2(...)),
+//	)
+func Scopes(scopes ...Scope) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, s := range scopes {
+			if s != nil {
+				db = s(db)
+			}
+		}
+		return db
+	}
+}
+
+// Or 把多个查询范围的条件用 OR 组合为一组，并以 AND 附加到外层条件上，
+// 例如 Or(Like("name", q), Like("email", q)) 生成 `(name LIKE ? OR email LIKE ?)`。
+// 每个查询范围在独立的新会话上执行，只取其 WHERE 条件，排序、分页等其他设置会被忽略；
+// 单个查询范围产生的多个条件之间仍为 AND。没有产生条件的查询范围（如 WhereIf 未命中）会被跳过。
+func Or(scopes ...Scope) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		var group *gorm.DB
+		for _, s := range scopes {
+			if s == nil {
+				continue
+			}
+			sub := s(db.Session(&gorm.Session{NewDB: true}))
+			if sub.Error != nil {
+				_ = db.AddError(sub.Error)
+				continue
+			}
+			if _, ok := sub.Statement.Clauses["WHERE"]; !ok {
+				continue
+			}
+			if group == nil {
+				group = sub
+			} else {
+				group = group.Or(sub)
+			}
+		}
+		if group == nil {
+			return db
+		}
+		return db.Where(group)
+	}
+}
+
+// WithoutDefaultScopes 返回一个跳过默认查询范围的查询范围。
+// 应用后，该语句不会再附加 SetDefaultScopes 设置的条件，
+// 适合需要跨租户或访问已删除数据的管理、维护类操作。
+func WithoutDefaultScopes() Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		db.Statement.Settings.Store(skipDefaultScopesKey, true)
+		return db
+	}
+}
+
+// skipDefaultScopesKey 是 WithoutDefaultScopes 在 Statement.Settings 中使用的键。
+const skipDefaultScopesKey = "gormx:skip_default_scopes"
+
+// DryRun 返回一个开启 DryRun 模式的查询范围。
+// 应用后，后续的终结方法（Find、Create、Update 等）只生成 SQL 而不会访问数据库，
+// 生成的语句和参数可以从返回结果的 Statement.SQL 和 Statement.Vars 中读取。
+func DryRun() Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		// 传入 Context 以复制 Statement，使其关联到新的会话，
+		// 否则执行结束时会按原会话的配置清空生成的 SQL。
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return db.Session(&gorm.Session{DryRun: true, Context: ctx})
+	}
+}
+
+// QueryFields 返回一个开启 QueryFields 模式的查询范围。
+// 应用后，查询会根据模型字段生成 `SELECT table.col1, table.col2, ...` 而不是 `SELECT *`，
+// 在滚动发布期间表结构有增删列时，可以避免查询到模型中不存在的列。
+func QueryFields() Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Session(&gorm.Session{QueryFields: true})
+	}
+}
+
+// funcs 将 Scope 列表转换为 gorm.DB.Scopes 接受的函数列表。
+func funcs(scopes []Scope) []func(*gorm.DB) *gorm.DB {
+	fs := make([]func(*gorm.DB) *gorm.DB, len(scopes))
+	for i, s := range scopes {
+		fs[i] = s
+	}
+	return fs
+}
+
+// errScope 返回一个只向 db 添加错误的 Scope，用于参数校验失败的场景。
+func errScope(err error) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		_ = db.AddError(err)
+		return db
+	}
+}
+
 // Like 创建一个查询范围，用于在数据库查询中添加LIKE条件。
 // 该函数主要用于实现模糊查询，通过在指定列中搜索包含查询字符串q的项。
+// q 中的 % 和 _ 不会被转义，仍然作为通配符使用，Prefix、Suffix、NotLike 也是如此。
 //
 // 参数:
 //
-//	column: 数据库列名，表示要在哪一列中进行模糊查询。
+//	col: 数据库列名，表示要在哪一列中进行模糊查询，可以带表名，例如 users.name。
 //	q: 查询字符串，表示要搜索的关键字。
 //
 // 返回值:
 //
 //	Scope: 返回一个函数，该函数接收一个*gorm.DB实例，并返回添加了LIKE条件的*gorm.DB实例。
-func Like(column, q string) Scope {
+func Like(col, q string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? LIKE ?", column(col), "%"+q+"%")
+	}
+}
+
+// NotLike 与 Like 相反，创建一个排除包含查询字符串 q 的记录的查询范围，生成 `col NOT LIKE '%q%'`。
+// 通配符的处理与 Like 一致。
+//
+// 参数:
+//
+//	col: 数据库列名，可以带表名。
+//	q: 需要排除的关键字。
+func NotLike(col, q string) Scope {
 	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("? LIKE ?", clause.Column{Name: column}, "%"+q+"%")
+		return db.Where("? NOT LIKE ?", column(col), "%"+q+"%")
+	}
+}
+
+// ILike 生成一个不区分大小写的模糊匹配查询范围，匹配包含 q 的值。
+// 方言支持 ILIKE 时（postgres）生成 `col ILIKE ?`，
+// 其他方言（mysql、sqlite 等）生成 `LOWER(col) LIKE LOWER(?)`。
+// 方言在执行查询时根据 db.Dialector.Name() 判断。
+//
+// 参数:
+//
+//	col: 要应用模糊匹配的数据库列名，可以带表名，例如 users.name
+//	q: 要匹配的字符串
+func ILike(col, q string) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		if Supports(db, FeatureILike) {
+			return db.Where("? ILIKE ?", c, "%"+q+"%")
+		}
+		return db.Where("LOWER(?) LIKE LOWER(?)", c, "%"+q+"%")
 	}
 }
 
@@ -64,6 +239,889 @@ func Suffix(col, q string) Scope {
 	}
 }
 
+// maxInSize 是 In/NotIn 单个 IN 列表的最大元素数量，0 表示不限制。
+var maxInSize = 0
+
+// SetMaxInSize 设置 In/NotIn 单个 IN 列表的最大元素数量，避免生成超长的 IN 列表。
+// 元素数量超过 n 时，In 拆分为多个以 OR 连接的 `col IN (...)`，NotIn 拆分为多个以 AND 连接的 `col NOT IN (...)`，
+// 每组最多 n 个元素，语义与不拆分时相同。n 小于等于 0 表示不限制，这是默认值。
+func SetMaxInSize(n int) { maxInSize = max(n, 0) }
+
+// In 创建一个 `col IN (?)` 条件的查询范围。
+// values 为空时保证生成恒为假的条件 `1 = 0`，而不是 gorm 默认的 `IN (NULL)`；
+// 元素数量超过 SetMaxInSize 设置的上限时拆分为多组，见 SetMaxInSize。
+//
+// 参数:
+//
+//	col: 数据库列名，可以带表名，例如 users.id。
+//	values: 候选值。
+func In[T any](col string, values []T) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		if len(values) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(inChunks(c, "IN", " OR ", values))
+	}
+}
+
+// NotIn 创建一个 `col NOT IN (?)` 条件的查询范围。
+// values 为空时保证生成恒为真的条件 `1 = 1`，即不排除任何记录；
+// 元素数量超过 SetMaxInSize 设置的上限时拆分为多组，见 SetMaxInSize。
+//
+// 参数:
+//
+//	col: 数据库列名，可以带表名。
+//	values: 需要排除的值。
+func NotIn[T any](col string, values []T) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		if len(values) == 0 {
+			return db.Where("1 = 1")
+		}
+		return db.Where(inChunks(c, "NOT IN", " AND ", values))
+	}
+}
+
+// inChunks 生成 `col op (values)` 条件，values 超过 maxInSize 时按 maxInSize 分组并以 sep 连接。
+func inChunks[T any](c clause.Column, op, sep string, values []T) clause.Expr {
+	if maxInSize <= 0 || len(values) <= maxInSize {
+		return clause.Expr{SQL: "? " + op + " ?", Vars: []any{c, values}}
+	}
+
+	var (
+		parts []string
+		vars  []any
+	)
+	for chunk := range slices.Chunk(values, maxInSize) {
+		parts = append(parts, "? "+op+" ?")
+		vars = append(vars, c, chunk)
+	}
+	return clause.Expr{SQL: strings.Join(parts, sep), Vars: vars}
+}
+
+// StaticIn 创建一个与 In 语义相同的查询范围，但无论 values 有多少个元素，生成的 SQL 文本都保持不变，
+// 避免长度不同的 IN 列表使预编译语句缓存（PrepareStmt）不断膨胀。
+// values 作为单个参数传入：
+//
+//	postgres  - col = ANY(?)，参数为数组字面量，例如 {"1","2"}
+//	mysql     - col MEMBER OF(CAST(? AS JSON))，参数为 JSON 数组，需要 MySQL 8.0.17 及以上
+//	sqlite    - col IN (SELECT value FROM json_each(?))，参数为 JSON 数组
+//	sqlserver - col IN (SELECT value FROM OPENJSON(?))，参数为 JSON 数组
+//
+// 其他数据库回退为 In。values 为空时与 In 一样生成恒为假的条件 `1 = 0`。
+//
+// 参数:
+//
+//	col: 数据库列名，可以带表名。
+//	values: 候选值，应为数字、字符串等基础类型。
+func StaticIn[T any](col string, values []T) Scope {
+	if len(values) == 0 {
+		return In(col, values)
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return errScope(fmt.Errorf("marshal static in values: %w", err))
+	}
+
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		switch dialectName(db) {
+		case "postgres":
+			return db.Where("? = ANY(?)", c, pgArray(values))
+		case "mysql":
+			return db.Where("? MEMBER OF(CAST(? AS JSON))", c, string(data))
+		case "sqlite":
+			return db.Where("? IN (SELECT value FROM json_each(?))", c, string(data))
+		case "sqlserver":
+			return db.Where("? IN (SELECT value FROM OPENJSON(?))", c, string(data))
+		default:
+			return In(col, values)(db)
+		}
+	}
+}
+
+// pgArray 把 values 格式化为 postgres 的数组字面量，每个元素都加双引号，由数据库按列类型转换。
+func pgArray[T any](values []T) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`"` + escape.Replace(fmt.Sprint(v)) + `"`)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// InEnum 创建一个 `col = value` 条件的查询范围，但只在 value 属于 allowed 时生效，
+// 否则生成恒为假的条件 `1 = 0`，使非法的枚举过滤值返回空结果而不是在查询中报错。
+// 适合状态等取值固定的过滤参数。
+//
+// 参数:
+//
+//	col: 数据库列名，可以带表名。
+//	value: 过滤值。
+//	allowed: 允许的枚举值。
+func InEnum[T comparable](col string, value T, allowed ...T) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		if !slices.Contains(allowed, value) {
+			return db.Where("1 = 0")
+		}
+		return db.Where("? = ?", c, value)
+	}
+}
+
+// Between 创建一个 `col BETWEEN lo AND hi` 条件的查询范围，包含两端。
+// lo 大于 hi 时会交换两者，保证区间有效。
+func Between[T cmp.Ordered](col string, lo, hi T) Scope {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? BETWEEN ? AND ?", c, lo, hi)
+	}
+}
+
+// Gte 创建一个 `col >= v` 条件的查询范围。
+func Gte(col string, v any) Scope { return compare(col, ">=", v) }
+
+// Lte 创建一个 `col <= v` 条件的查询范围。
+func Lte(col string, v any) Scope { return compare(col, "<=", v) }
+
+// Gt 创建一个 `col > v` 条件的查询范围。
+func Gt(col string, v any) Scope { return compare(col, ">", v) }
+
+// Lt 创建一个 `col < v` 条件的查询范围。
+func Lt(col string, v any) Scope { return compare(col, "<", v) }
+
+// IsNull 创建一个 `col IS NULL` 条件的查询范围。
+func IsNull(col string) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? IS NULL", c)
+	}
+}
+
+// IsNotNull 创建一个 `col IS NOT NULL` 条件的查询范围。
+func IsNotNull(col string) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? IS NOT NULL", c)
+	}
+}
+
+// DurationGte 创建一个 `col >= d` 条件的查询范围，用于以整数存储时长的列。
+// unit 为列存储的单位，例如 time.Millisecond、time.Second，省略时为纳秒（time.Duration 本身的单位）。
+// d 不是 unit 的整数倍时向上取整，保证不会匹配到小于 d 的记录。
+func DurationGte(col string, d time.Duration, unit ...time.Duration) Scope {
+	u := durationUnit(unit)
+	n := int64(d / u)
+	if d%u > 0 {
+		n++
+	}
+	return compare(col, ">=", n)
+}
+
+// DurationLte 创建一个 `col <= d` 条件的查询范围，单位规则同 DurationGte，
+// d 不是 unit 的整数倍时向下取整，保证不会匹配到大于 d 的记录。
+func DurationLte(col string, d time.Duration, unit ...time.Duration) Scope {
+	u := durationUnit(unit)
+	n := int64(d / u)
+	if d%u < 0 {
+		n--
+	}
+	return compare(col, "<=", n)
+}
+
+// durationUnit 返回第一个大于 0 的单位，没有时为纳秒。
+func durationUnit(unit []time.Duration) time.Duration {
+	for _, u := range unit {
+		if u > 0 {
+			return u
+		}
+	}
+	return time.Nanosecond
+}
+
+func compare(col, op string, v any) Scope {
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? "+op+" ?", c, v)
+	}
+}
+
+// inLargeThreshold 是 InLarge 改为单个数组参数的元素数量阈值。
+var inLargeThreshold = 1000
+
+// InLarge 创建一个 `col IN (...)` 条件的查询范围，适用于元素数量很多的情况。
+// 元素数量超过 1000 时，postgres 和 sqlite 上把全部元素作为一个参数传入，避免逐个展开绑定参数：
+//
+//	postgres - col = ANY(?)，参数为数组字面量，由数据库按列类型转换，与 StaticIn 相同
+//	sqlite   - col IN (SELECT value FROM json_each(?))，参数为 JSON 数组
+//
+// 数据库会把数组当作一个内联表进行关联，也不受参数数量上限的约束；其他情况与 In 相同。
+func InLarge[T any](col string, values []T) Scope {
+	c, in := column(col), In(col, values)
+	return func(db *gorm.DB) *gorm.DB {
+		if len(values) <= inLargeThreshold {
+			return in(db)
+		}
+		switch dialectName(db) {
+		case "postgres":
+			return db.Where("? = ANY(?)", c, pgArray(values))
+		case "sqlite":
+			data, err := json.Marshal(values)
+			if err != nil {
+				_ = db.AddError(fmt.Errorf("marshal in values: %w", err))
+				return db
+			}
+			return db.Where("? IN (SELECT value FROM json_each(?))", c, string(data))
+		}
+		return in(db)
+	}
+}
+
+// compareOps 是允许在条件中使用的比较运算符。
+var compareOps = map[string]bool{"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// ColumnCompare 创建一个比较两列的查询范围，生成形如 `left op right` 的条件，不绑定任何参数。
+// 两个列名都会经过 column() 处理并由方言加上引号，op 只允许 =、<>、!=、<、<=、>、>=，
+// 其他运算符会使查询返回错误。
+//
+// 例如 ColumnCompare("updated_at", ">", "created_at")。
+func ColumnCompare(left, op, right string) Scope {
+	if !compareOps[op] {
+		return errScope(fmt.Errorf("invalid compare operator: %q", op))
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? "+op+" ?", column(left), column(right))
+	}
+}
+
+// WhereFunc 创建一个对列应用数据库函数后再比较的查询范围，生成形如 `funcExpr op value` 的条件。
+// funcExpr 中的每个 `?` 依次替换为 columns 中的列，列名会经过 column() 处理并由方言加上引号，value 作为参数绑定。
+// op 的取值与 ColumnCompare 相同；运算符非法或占位符数量与列数不一致时查询返回错误。
+//
+// 例如 WhereFunc("lower(?)", "=", "a@b.c", "email") 生成 `lower("email") = 'a@b.c'`，
+// WhereFunc("date(?)", ">=", day, "created_at") 生成 `date("created_at") >= day`。
+func WhereFunc(funcExpr, op string, value any, columns ...string) Scope {
+	if !compareOps[op] {
+		return errScope(fmt.Errorf("invalid compare operator: %q", op))
+	}
+	if n := strings.Count(funcExpr, "?"); n != len(columns) {
+		return errScope(fmt.Errorf("where func %q expects %d columns, got %d", funcExpr, n, len(columns)))
+	}
+
+	vars := make([]any, 0, len(columns)+1)
+	for _, c := range columns {
+		vars = append(vars, column(c))
+	}
+	vars = append(vars, value)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(funcExpr+" "+op+" ?", vars...)
+	}
+}
+
+// HavingAlias 创建一个按 SELECT 别名过滤聚合结果的查询范围，生成 `HAVING alias op value`。
+// mysql 和 sqlite 允许在 HAVING 中引用别名，直接使用加上引号的别名；
+// postgres、sqlserver 等不允许，此时从 Select 指定的列中找到 `expr AS alias` 并在 HAVING 中重复 expr，
+// 因此应在 Select 之后应用，找不到对应的选择项时查询返回错误。value 作为参数绑定，op 的取值与 ColumnCompare 相同。
+//
+// 例如 db.Select("user_id, SUM(amount) AS total").Group("user_id").Scopes(HavingAlias("total", ">", 100))
+// 在 mysql 上生成 `HAVING total > 100`，在 postgres 上生成 `HAVING SUM(amount) > 100`。
+func HavingAlias(alias, op string, value any) Scope {
+	if !compareOps[op] {
+		return errScope(fmt.Errorf("invalid compare operator: %q", op))
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		switch dialectName(db) {
+		case "mysql", "sqlite":
+			return db.Having("? "+op+" ?", clause.Column{Name: alias}, value)
+		}
+		expr, ok := selectAliasExpr(db.Statement.Selects, alias)
+		if !ok {
+			_ = db.AddError(fmt.Errorf("having alias: no select expression for alias %q", alias))
+			return db
+		}
+		return db.Having(expr+" "+op+" ?", value)
+	}
+}
+
+// UpsertOn 创建一个为插入语句添加冲突处理的查询范围，
+// 使 db.Scopes(UpsertOn(...)).Create(&records) 在冲突时更新已有记录。
+//
+// conflictColumns 为判断冲突的列（唯一索引或主键），为空时由数据库根据主键判断；
+// updateColumns 为冲突时需要更新的列，为空时更新除主键外的全部列。
+// 具体的 SQL（ON CONFLICT、ON DUPLICATE KEY UPDATE、MERGE 等）由方言生成。
+func UpsertOn(conflictColumns []string, updateColumns ...string) Scope {
+	onConflict := upsertClause(conflictColumns, updateColumns)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(onConflict)
+	}
+}
+
+// UpsertOnWhere 与 UpsertOn 相同，但为冲突目标附加 WHERE 条件，
+// 生成 `ON CONFLICT (cols) WHERE where DO UPDATE ...`，用于 postgres 上基于部分唯一索引的冲突判断，
+// where 应与部分索引的条件一致，例如 gorm.Expr("deleted_at IS NULL")。
+//
+// 只有 postgres 支持该条件，其他方言会忽略 where 并记录一条警告，其余行为与 UpsertOn 相同。
+func UpsertOnWhere(conflictColumns []string, where clause.Expression, updateColumns ...string) Scope {
+	onConflict := upsertClause(conflictColumns, updateColumns)
+	return func(db *gorm.DB) *gorm.DB {
+		oc := onConflict
+		switch name := dialectName(db); {
+		case where == nil:
+		case name == "postgres":
+			oc.TargetWhere = clause.Where{Exprs: []clause.Expression{where}}
+		default:
+			slog.Warn("[sql] conflict target where is only supported by postgres, ignored", "dialect", name)
+		}
+		return db.Clauses(oc)
+	}
+}
+
+// upsertClause 根据冲突列和需要更新的列构建 clause.OnConflict，规则见 UpsertOn。
+func upsertClause(conflictColumns, updateColumns []string) clause.OnConflict {
+	onConflict := clause.OnConflict{}
+	for _, c := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column(c).Name})
+	}
+	if len(updateColumns) == 0 {
+		onConflict.UpdateAll = true
+	} else {
+		names := make([]string, len(updateColumns))
+		for i, c := range updateColumns {
+			names[i] = column(c).Name
+		}
+		onConflict.DoUpdates = clause.AssignmentColumns(names)
+	}
+	return onConflict
+}
+
+// SelectColumns 创建一个只操作指定列的查询范围，等同于 db.Select(columns...)，列名会经过 column() 清理。
+//
+// 用在不同语句上时含义不同:
+//
+//	Create - 只写入指定的列，其余列由数据库默认值填充。
+//	Update/Updates - 只更新指定的列，即使对应字段为零值也会更新。
+//	Find/First 等查询 - 只查询指定的列。
+func SelectColumns(columns ...string) Scope {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = column(c).Name
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Select(names)
+	}
+}
+
+// Returning 创建一个为 INSERT/UPDATE/DELETE 语句添加 RETURNING 子句的查询范围，
+// 只返回指定的列，例如新生成的 id 和 created_at；columns 为空时为 RETURNING *。
+// 方言不支持 RETURNING 时（见 Supports 和 FeatureReturning）该查询范围不做任何修改。
+func Returning(columns ...string) Scope {
+	returning := clause.Returning{}
+	for _, c := range columns {
+		returning.Columns = append(returning.Columns, clause.Column{Name: column(c).Name})
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if !Supports(db, FeatureReturning) {
+			return db
+		}
+		return db.Clauses(returning)
+	}
+}
+
+// lockStrict 为 true 时，在事务外使用加锁查询范围会使查询返回错误，否则只记录警告。
+var lockStrict = false
+
+// SetLockStrict 设置在事务外使用加锁查询范围（ForUpdate、ForUpdateNoWait 等）时的处理方式。
+// 默认只通过 slog 记录一条警告，strict 为 true 时查询直接返回错误。
+// 行锁只在事务中才有意义，事务外的锁在语句结束时立即释放，这通常是忘记开启事务导致的错误。
+func SetLockStrict(strict bool) { lockStrict = strict }
+
+// ForUpdate 创建一个以 `FOR UPDATE` 锁定查询行的查询范围。
+// 需要在事务中使用，锁会在事务结束时释放；在事务外使用时的处理见 SetLockStrict。
+// 是否真正加锁由方言决定（例如 sqlite 会忽略行锁）。
+func ForUpdate() Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return lock(db, clause.Locking{Strength: clause.LockingStrengthUpdate})
+	}
+}
+
+// ForUpdateNoWait 创建一个以 `FOR UPDATE NOWAIT` 锁定查询行的查询范围，
+// 行已被其他事务锁定时查询立即返回错误而不是等待。
+// 方言不支持 NOWAIT 时（见 Supports 和 FeatureNoWait）退化为普通的 `FOR UPDATE`，
+// 是否真正加锁由方言决定（例如 sqlite 会忽略行锁）。
+// 需要在事务中使用，锁会在事务结束时释放；在事务外使用时的处理见 SetLockStrict。
+func ForUpdateNoWait() Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		locking := clause.Locking{Strength: clause.LockingStrengthUpdate}
+		if Supports(db, FeatureNoWait) {
+			locking.Options = clause.LockingOptionsNoWait
+		}
+		return lock(db, locking)
+	}
+}
+
+// ForUpdateOf 创建一个以 `FOR UPDATE OF table, ...` 只锁定指定表的行的查询范围，
+// 用于多表关联查询时只锁定其中一部分表，表名会由方言加上引号；在 postgres 上通常应使用表的别名。
+// 方言不支持 OF 时（见 Supports 和 FeatureLockOf）或 tables 为空时退化为普通的 `FOR UPDATE`。
+// 需要在事务中使用，锁会在事务结束时释放；在事务外使用时的处理见 SetLockStrict。
+func ForUpdateOf(tables ...string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		locking := clause.Locking{Strength: clause.LockingStrengthUpdate}
+		if len(tables) > 0 && Supports(db, FeatureLockOf) {
+			quoted := make([]string, len(tables))
+			for i, t := range tables {
+				quoted[i] = db.Statement.Quote(clause.Table{Name: t})
+			}
+			locking.Table = clause.Table{Name: strings.Join(quoted, ", "), Raw: true}
+		}
+		return lock(db, locking)
+	}
+}
+
+// lock 为语句添加加锁子句，并检查语句是否在事务中执行。
+func lock(db *gorm.DB, locking clause.Locking) *gorm.DB {
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); !inTx && !db.DryRun {
+		if lockStrict {
+			_ = db.AddError(fmt.Errorf("locking clause %s used outside of a transaction", locking.Strength))
+			return db
+		}
+		slog.Warn("[sql] locking clause used outside of a transaction, the lock is released immediately", "strength", locking.Strength)
+	}
+	return db.Clauses(locking)
+}
+
+// Comment 创建一个在语句中添加 SQL 注释的查询范围，生成形如 `/* service:api,endpoint:list_users */ SELECT ...` 的语句，
+// 便于在慢查询日志等地方追踪语句的来源（sqlcommenter 模式）。
+// 查询、更新和删除语句的注释写在语句开头；插入语句的注释写在 INSERT 关键字之后（`INSERT /* ... */ INTO`），
+// 因为部分方言会自行构建 INSERT 子句。多次调用时注释按顺序合并为一个。
+//
+// text 中的 `*/` 和 `/*` 会被拆开，避免提前结束注释而注入 SQL；
+// `?` 会被替换为 `%3F`，避免被当作参数占位符。
+func Comment(text string) Scope {
+	text = strings.NewReplacer("*/", "* /", "/*", "/ *", "?", "%3F").Replace(text)
+	return func(db *gorm.DB) *gorm.DB {
+		var comment sqlComment
+		if v, ok := db.Statement.Settings.Load(commentKey); ok {
+			comment = v.(sqlComment)
+		}
+		comment = append(slices.Clip(comment), text)
+		db.Statement.Settings.Store(commentKey, comment)
+
+		for _, name := range []string{"SELECT", "UPDATE", "DELETE"} {
+			c := db.Statement.Clauses[name]
+			c.BeforeExpression = comment
+			db.Statement.Clauses[name] = c
+		}
+
+		// INSERT 写在修饰符的位置，保留已有的修饰符（例如 IGNORE）。
+		c := db.Statement.Clauses["INSERT"]
+		insert, _ := c.Expression.(clause.Insert)
+		if _, modifier, ok := strings.Cut(insert.Modifier, "*/"); ok && strings.HasPrefix(insert.Modifier, "/*") {
+			insert.Modifier = strings.TrimSpace(modifier)
+		}
+		insert.Modifier = strings.TrimSpace(comment.String() + " " + insert.Modifier)
+		c.Name, c.Expression = "INSERT", insert
+		db.Statement.Clauses["INSERT"] = c
+		return db
+	}
+}
+
+// commentKey 是 Comment 在 Statement.Settings 中保存注释的键。
+const commentKey = "gormx:comment"
+
+// sqlComment 是添加到语句中的 SQL 注释。
+type sqlComment []string
+
+func (c sqlComment) String() string { return "/* " + strings.Join(c, ", ") + " */" }
+
+func (c sqlComment) Build(builder clause.Builder) { builder.WriteString(c.String()) }
+
+// SQLServerHint 创建一个为 SELECT 语句追加 SQL Server 查询提示的查询范围，
+// 生成形如 `SELECT ... OPTION (RECOMPILE)` 的语句，多次调用的提示会合并到同一个 OPTION 中。
+// 只在 sqlserver 方言上生效，其他方言以及 INSERT/UPDATE/DELETE 语句不受影响。
+//
+// 注意：hint 会原样写入 SQL，不能来自用户输入。
+//
+// 例如 SQLServerHint("RECOMPILE")、SQLServerHint("MAXDOP 1")。
+func SQLServerHint(hint string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if dialectName(db) != "sqlserver" || hint == "" {
+			return db
+		}
+		return db.Clauses(sqlServerHint{hint})
+	}
+}
+
+// sqlServerHint 是 SQL Server 的 OPTION 查询提示子句。
+// 它占用查询语句末尾的 FOR 子句位置（SQL Server 不使用 FOR UPDATE 加锁），因此只会出现在 SELECT 语句中。
+type sqlServerHint []string
+
+func (h sqlServerHint) Name() string { return "FOR" }
+
+func (h sqlServerHint) Build(builder clause.Builder) {
+	builder.WriteString("OPTION (" + strings.Join(h, ", ") + ")")
+}
+
+func (h sqlServerHint) MergeClause(c *clause.Clause) {
+	c.Name = ""
+	if existing, ok := c.Expression.(sqlServerHint); ok {
+		h = append(slices.Clip(existing), h...)
+	}
+	c.Expression = h
+}
+
+// jsonPathKey 匹配 JSON 路径中的单个键。
+var jsonPathKey = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// IndexedBy 创建一个在 sqlite 上强制查询使用指定索引的查询范围，在表名之后生成 `INDEXED BY index`，
+// 用于 sqlite 查询规划器选错索引的场景；索引不存在时 sqlite 会返回错误。其他方言不做处理。
+// 它通过 FROM 子句实现，不能与自定义的 clause.From 同时使用。
+func IndexedBy(index string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if dialectName(db) != "sqlite" || index == "" {
+			return db
+		}
+		hint := clause.Expr{SQL: "INDEXED BY ?", Vars: []any{clause.Table{Name: index}}}
+		return db.Clauses(clause.From{Joins: []clause.Join{{Expression: hint}}})
+	}
+}
+
+// JSONEquals 创建一个查询范围，用于比较 JSON 列中指定路径的值。
+// 路径使用点号分隔的键，例如 "status" 或 "profile.city"，也可以带上 "$." 前缀。
+// 每个键只允许字母、数字和下划线，以避免注入，非法路径会使查询返回错误。
+//
+// 根据方言生成不同的 SQL:
+//
+//	sqlite    - json_extract(col, '$.a.b') = ?
+//	mysql     - JSON_EXTRACT(col, '$.a.b') = ?
+//	postgres  - col->'a'->>'b' = ?
+//	sqlserver - JSON_VALUE(col, '$.a.b') = ?
+//
+// postgres 的 ->> 返回文本，value 会先转换为与之对应的文本再比较：字符串保持原样，
+// 数字和布尔值使用其 JSON 表示（例如 1、true），value 为 nil 时生成 IS NULL。
+func JSONEquals(col, path string, value any) Scope {
+	keys := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, "$"), "."), ".")
+	for _, key := range keys {
+		if !jsonPathKey.MatchString(key) {
+			return errScope(fmt.Errorf("invalid json path: %q", path))
+		}
+	}
+
+	c := column(col)
+	p := "$." + strings.Join(keys, ".")
+
+	return func(db *gorm.DB) *gorm.DB {
+		switch name := dialectName(db); name {
+		case "sqlite":
+			return db.Where("json_extract(?, ?) = ?", c, p, value)
+		case "mysql":
+			return db.Where("JSON_EXTRACT(?, ?) = ?", c, p, value)
+		case "sqlserver":
+			return db.Where("JSON_VALUE(?, ?) = ?", c, p, value)
+		case "postgres":
+			var sql strings.Builder
+			sql.WriteString("?")
+			for i, key := range keys {
+				if i == len(keys)-1 {
+					sql.WriteString("->>'" + key + "'")
+				} else {
+					sql.WriteString("->'" + key + "'")
+				}
+			}
+			if value == nil {
+				sql.WriteString(" IS NULL")
+				return db.Where(sql.String(), c)
+			}
+			text, err := jsonText(value)
+			if err != nil {
+				_ = db.AddError(fmt.Errorf("json value: %w", err))
+				return db
+			}
+			sql.WriteString(" = ?")
+			return db.Where(sql.String(), c, text)
+		default:
+			_ = db.AddError(fmt.Errorf("json path is not supported by dialect: %s", name))
+			return db
+		}
+	}
+}
+
+// jsonText 返回 value 经 postgres 的 ->> 取出后的文本形式，字符串和字节切片原样返回，其他值使用 JSON 编码。
+func jsonText(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+// PreloadSelect 创建一个查询范围，预加载关联 association 时只查询指定的列。
+// 为了让 GORM 能够把关联记录回填到父记录上，关联所需的外键（或多对多关系中关联表的主键）
+// 会被自动加入查询列。columns 为空时等同于普通的 Preload，查询全部列。
+// association 支持以点号分隔的嵌套关联，例如 "Orders.Items"。
+//
+// 参数:
+//
+//	association: 关联字段名称。
+//	columns: 需要查询的列名。
+func PreloadSelect(association string, columns ...string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(columns) == 0 {
+			return db.Preload(association)
+		}
+
+		stmt := db.Statement
+		return db.Preload(association, func(tx *gorm.DB) *gorm.DB {
+			// 预加载执行时，主查询的模型已经解析完成，可以据此找到关联关系。
+			selects := make([]string, 0, len(columns)+1)
+			for _, c := range columns {
+				selects = append(selects, column(c).Name)
+			}
+			if stmt.Schema != nil {
+				selects = append(selects, preloadKeys(stmt.Schema, association)...)
+			}
+			return tx.Select(slices.Compact(slices.Sorted(slices.Values(selects))))
+		})
+	}
+}
+
+// Preloads 创建一个依次预加载多个关联的查询范围，等同于对每个关联调用 db.Preload。
+// associations 为空时不做任何处理。
+//
+// 参数:
+//
+//	associations: 关联字段名称，支持以点号分隔的嵌套关联。
+func Preloads(associations ...string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, association := range associations {
+			db = db.Preload(association)
+		}
+		return db
+	}
+}
+
+// PreloadUnscoped 创建一个查询范围，预加载关联 association 时包含已软删除的关联记录。
+// 只有预加载关联的子查询会应用 Unscoped，主查询的软删除过滤保持不变。
+// association 支持以点号分隔的嵌套关联，此时 Unscoped 只作用于最后一级关联。
+//
+// 参数:
+//
+//	association: 关联字段名称。
+func PreloadUnscoped(association string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload(association, func(tx *gorm.DB) *gorm.DB {
+			return tx.Unscoped()
+		})
+	}
+}
+
+// preloadKeys 返回预加载关联 association 时，关联表上必须查询的列。
+func preloadKeys(s *schema.Schema, association string) (keys []string) {
+	var rel *schema.Relationship
+	for _, name := range strings.Split(association, ".") {
+		if rel != nil {
+			s = rel.FieldSchema
+		}
+		if rel = s.Relationships.Relations[name]; rel == nil {
+			return nil
+		}
+	}
+
+	for _, ref := range rel.References {
+		if ref.OwnPrimaryKey && rel.JoinTable == nil {
+			keys = append(keys, ref.ForeignKey.DBName)
+		} else if !ref.OwnPrimaryKey && ref.PrimaryValue == "" {
+			keys = append(keys, ref.PrimaryKey.DBName)
+		}
+	}
+	return
+}
+
+// SelectSubquery 创建一个查询范围，把标量子查询 sub 作为别名为 alias 的列加入 SELECT 列表，
+// 生成形如 `(SELECT COUNT(*) FROM orders WHERE ...) AS order_count` 的列，子查询的绑定参数会被保留。
+// 多次调用会依次追加列；之前通过 Select 指定的列会被保留，未指定时先查询当前表的全部列。
+//
+// 参数:
+//
+//	alias: 子查询列的别名，会由方言加上引号。
+//	sub: 子查询，通常为 db.Model(...).Select(...).Where(...) 构建的 *gorm.DB。
+func SelectSubquery(alias string, sub *gorm.DB) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return appendSelect(db, clause.Expr{SQL: "(?) AS ?", Vars: []any{sub, clause.Column{Name: alias}}})
+	}
+}
+
+// FromSubquery 创建一个把查询的 FROM 替换为 `(sub) AS alias` 的查询范围，
+// 外层的条件、排序等作用于子查询的结果，可用于在部分数据库上强制物化派生表以得到更好的执行计划。
+// 子查询的绑定参数会被保留；当前表名被设置为 alias，Like、Gt 等查询范围中未带表名的列会引用 alias。
+//
+// 参数:
+//
+//	sub: 子查询，通常为 db.Model(...).Where(...) 构建的 *gorm.DB。
+//	alias: 子查询的别名，会由方言加上引号。
+func FromSubquery(sub *gorm.DB, alias string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Table("(?) AS ?", sub, clause.Table{Name: alias})
+		db.Statement.Table = alias
+		return db
+	}
+}
+
+// RowNumber 创建一个把 `ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...) AS alias` 加入 SELECT 列表的查询范围，
+// 常用于“每组取前 N 条”的查询。SELECT 列表的处理规则同 SelectSubquery。
+//
+// partitionBy 和 orderBy 都可以是逗号分隔的多个列，为空时省略对应部分；
+// orderBy 中以 '-' 开头的列为降序，规则同 ParseOrderBy。列名和别名都会由方言加上引号。
+// 方言不支持窗口函数时（见 Supports 和 FeatureWindow）查询会返回错误。
+//
+// 例如 RowNumber("rn", "user_id", "-created_at") 生成
+// `ROW_NUMBER() OVER (PARTITION BY "user_id" ORDER BY "created_at" DESC) AS "rn"`。
+func RowNumber(alias, partitionBy, orderBy string) Scope {
+	var (
+		sql  strings.Builder
+		vars []any
+	)
+
+	sql.WriteString("ROW_NUMBER() OVER (")
+	for i, it := range ParseOrderBy(partitionBy) {
+		if i == 0 {
+			sql.WriteString("PARTITION BY ?")
+		} else {
+			sql.WriteString(", ?")
+		}
+		vars = append(vars, column(it.Column))
+	}
+	for i, it := range ParseOrderBy(orderBy) {
+		switch {
+		case i == 0 && len(vars) > 0:
+			sql.WriteString(" ORDER BY ?")
+		case i == 0:
+			sql.WriteString("ORDER BY ?")
+		default:
+			sql.WriteString(", ?")
+		}
+		if it.Desc {
+			sql.WriteString(" DESC")
+		}
+		vars = append(vars, column(it.Column))
+	}
+	sql.WriteString(") AS ?")
+	vars = append(vars, clause.Column{Name: alias})
+
+	expr := clause.Expr{SQL: sql.String(), Vars: vars}
+	return func(db *gorm.DB) *gorm.DB {
+		if !Supports(db, FeatureWindow) {
+			_ = db.AddError(fmt.Errorf("window functions are not supported by dialect: %s", dialectName(db)))
+			return db
+		}
+		return appendSelect(db, expr)
+	}
+}
+
+// selectList 是由多个表达式组成的 SELECT 列表，以逗号分隔。
+type selectList []clause.Expression
+
+func (l selectList) Build(builder clause.Builder) {
+	for i, expr := range l {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		expr.Build(builder)
+	}
+}
+
+// appendSelect 向 SELECT 列表追加表达式。
+// 已有的 SELECT 表达式或 Select 指定的列会保留在列表开头，二者都没有时以 `table`.* 开头。
+func appendSelect(db *gorm.DB, exprs ...clause.Expression) *gorm.DB {
+	var list selectList
+	switch existing := db.Statement.Clauses["SELECT"].Expression.(type) {
+	case selectList:
+		list = existing
+	case nil:
+		for _, s := range db.Statement.Selects {
+			if strings.ContainsAny(s, " ()*") {
+				list = append(list, clause.Expr{SQL: s})
+			} else {
+				list = append(list, clause.Expr{SQL: "?", Vars: []any{column(s)}})
+			}
+		}
+		if len(list) == 0 {
+			list = append(list, clause.Expr{SQL: "?.*", Vars: []any{clause.Table{Name: clause.CurrentTable}}})
+		}
+		db.Statement.Selects = nil
+	default:
+		list = selectList{existing}
+	}
+
+	list = append(slices.Clip(list), exprs...)
+	return db.Clauses(clause.Select{Distinct: db.Statement.Distinct, Expression: list})
+}
+
+// TimeWindow 创建一个按常用相对时间范围过滤的查询范围，生成半开区间条件 `column >= start AND column < end`。
+//
+// window 可选值:
+//
+//	today      - 今天
+//	yesterday  - 昨天
+//	this_week  - 本周（周一开始）
+//	this_month - 本月
+//	last_7d    - 包含今天在内的最近 7 天
+//	last_30d   - 包含今天在内的最近 30 天
+//
+// 日期边界按 loc 所在时区计算，loc 为 nil 时使用 time.Local；未知的 window 会使查询返回错误。
+func TimeWindow(col, window string, loc *time.Location) Scope {
+	if loc == nil {
+		loc = time.Local
+	}
+	if _, _, ok := timeWindow(window, time.Now().In(loc)); !ok {
+		return errScope(fmt.Errorf("unknown time window: %q", window))
+	}
+
+	c := column(col)
+	return func(db *gorm.DB) *gorm.DB {
+		start, end, _ := timeWindow(window, time.Now().In(loc))
+		return db.Where("? >= ? AND ? < ?", c, start, c, end)
+	}
+}
+
+// timeWindow 计算 now 所在时区中 window 对应的 [start, end) 时间范围。
+func timeWindow(window string, now time.Time) (start, end time.Time, ok bool) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	tomorrow := today.AddDate(0, 0, 1)
+
+	switch window {
+	case "today":
+		return today, tomorrow, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, true
+	case "this_week":
+		start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7), true
+	case "this_month":
+		start = time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0), true
+	case "last_7d":
+		return today.AddDate(0, 0, -6), tomorrow, true
+	case "last_30d":
+		return today.AddDate(0, 0, -29), tomorrow, true
+	}
+	return
+}
+
 // Paging 是一个泛型函数，用于创建一个分页查询的范围。
 // 它接受页码（page）、每页大小（size）和一个可选的默认每页大小（defSize）作为参数。
 // 该函数返回一个 Scope 函数，该函数对传入的 *gorm.DB 实例应用分页逻辑。
@@ -79,10 +1137,8 @@ func Paging[T1 Integer, T2 Integer, T3 Integer](page T1, size T2, defSize ...T3)
 		}
 	}
 
-	// 如果每页大小为 0，则使用默认的每页大小。
-	if s == 0 {
-		s = d
-	}
+	// 规范化页码和每页大小：每页大小为 0 时使用默认值，小于 0 时不限制，规则与 PageMeta 相同。
+	p, s = pageClamp(p, s, d)
 
 	// 返回一个 Scope 函数，该函数对传入的 *gorm.DB 实例应用分页逻辑。
 	return func(db *gorm.DB) *gorm.DB {
@@ -95,54 +1151,273 @@ func Paging[T1 Integer, T2 Integer, T3 Integer](page T1, size T2, defSize ...T3)
 	}
 }
 
+// KeysetPaging 创建一个基于游标（keyset）的分页查询范围，避免大表上 OFFSET 分页越往后越慢的问题。
+// 生成 `col > lastValue ORDER BY col LIMIT size`，desc 为 true 时生成 `col < lastValue ORDER BY col DESC`，
+// 比较方向与排序方向保持一致，保证翻页结果稳定。col 应为唯一且不可为空的列（如主键）。
+// lastValue 为 nil 或零值时表示第一页，不附加游标条件；size 小于等于 0 时使用默认每页大小。
+//
+// 参数:
+//
+//	col: 游标列名，可以带表名。
+//	lastValue: 上一页最后一条记录的 col 值。
+//	size: 每页大小。
+//	desc: 是否按降序翻页。
+func KeysetPaging(col string, lastValue any, size int, desc bool) Scope {
+	c := column(col)
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		if lastValue != nil && !reflect.ValueOf(lastValue).IsZero() {
+			db = db.Where("? "+op+" ?", c, lastValue)
+		}
+		return db.Order(clause.OrderByColumn{Column: c, Desc: desc}).Limit(size)
+	}
+}
+
+// deepPageOffset 是 DeepPage 改用定位子查询的偏移量阈值。
+var deepPageOffset = 1000
+
+// DeepPage 创建一个按 sortColumn 升序分页的查询范围，适合页码很大的深分页。
+// 偏移量小于 1000 时等同于按 sortColumn 排序后应用 Paging；
+// 否则先用子查询 `SELECT col ... ORDER BY col LIMIT 1 OFFSET n` 找到该页第一条记录的 col 值，
+// 再以 `col >= 该值 ORDER BY col LIMIT size` 读取整页。子查询只读取 col，通常可以只扫描索引，
+// 避免普通 OFFSET 分页为跳过的每一行都读取完整记录。页码和每页大小的规范化规则与 Paging 相同。
+//
+// 精度上的取舍：sortColumn 必须唯一且不为 NULL（如主键），否则与定位值相同的记录会在相邻两页重复出现，
+// 深分页的结果会与普通分页不同。子查询复制了应用该查询范围时语句上已有的条件，因此应在其他条件之后应用。
+//
+// 参数:
+//
+//	sortColumn: 排序列名，可以带表名。
+//	page: 页码。
+//	size: 每页大小。
+func DeepPage(sortColumn string, page, size int) Scope {
+	p, n := pageClamp(page, size, defaultPageSize)
+	offset := (p - 1) * n
+	c := column(sortColumn)
+	order := clause.OrderByColumn{Column: c}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if offset < deepPageOffset {
+			return db.Order(order).Scopes(Paging[int, int, int](p, n))
+		}
+
+		sub := db.Session(&gorm.Session{}).Select("?", c)
+		delete(sub.Statement.Clauses, "ORDER BY")
+		delete(sub.Statement.Clauses, "LIMIT")
+		sub.Statement.Preloads = nil
+		sub = sub.Order(order).Offset(offset).Limit(1)
+
+		return db.Where("? >= (?)", c, sub).Order(order).Limit(n)
+	}
+}
+
+// DefaultLimit 创建一个只在尚未设置 LIMIT 时才应用 Limit(n) 的查询范围。
+// 应放在 Paging 等查询范围之后，这样已设置的分页大小优先，未设置时使用 n 作为默认上限。
+// 只设置了 Offset 而没有 Limit 时同样会应用 n。
+func DefaultLimit(n int) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if limit, ok := db.Statement.Clauses["LIMIT"].Expression.(clause.Limit); ok && limit.Limit != nil {
+			return db
+		}
+		return db.Limit(n)
+	}
+}
+
+// OrderItem 表示排序参数中的一个排序项。
+type OrderItem struct {
+	Column string // 排序的列名。
+	Desc   bool   // 是否降序。
+}
+
+// String 返回排序项对应的 SQL 片段，例如 "name ASC"、"age DESC"。
+func (it OrderItem) String() string {
+	if it.Desc {
+		return it.Column + " DESC"
+	}
+	return it.Column + " ASC"
+}
+
+// ParseOrderBy 解析排序参数字符串，返回结构化的排序项列表。
+// 排序参数以逗号分隔，每一项以 '-' 开头表示降序，否则为升序。
+// 各项会去除前后空格，空项以及单独的 '-' 会被忽略。
+//
+// 例如 "name, -age" 解析为 [{name false} {age true}]。
+func ParseOrderBy(s string) []OrderItem {
+	var items []OrderItem
+	for _, it := range strings.Split(s, ",") {
+		// 去除排序项的前后空格。
+		if it = strings.TrimSpace(it); it == "" || it == "-" {
+			continue
+		}
+		// 检查排序项是否以 '-' 开头，以确定是升序还是降序。
+		if it[0] == '-' {
+			items = append(items, OrderItem{Column: strings.TrimSpace(it[1:]), Desc: true})
+		} else {
+			items = append(items, OrderItem{Column: it})
+		}
+	}
+	return items
+}
+
 // OrderBy 根据传入的排序参数构建排序查询。
 // 该函数接收两个参数：orderBy 是用户指定的排序参数，def 是默认的排序参数。
 // 它返回一个 Scope 函数，该函数可以应用于 gorm.DB 对象以添加排序条件。
+//
+// 每个排序项的列名通过 column 解析，由方言加上引号，因此保留字和大小写敏感的列名也能正确排序；
+// 带表名的项（如 users.created_at）保留表名，未带表名的项不会附加当前表名，以便按 SELECT 中的别名排序。
 func OrderBy(orderBy string, def string) Scope {
 	// calc 是一个内部函数，用于处理排序字符串。
-	// 它接收一个字符串 in，并返回一个格式化后的排序字符串。
-	calc := func(in string) string {
-		if in != "" {
-			var x int
-			// 将输入字符串按逗号分割成多个排序项。
-			orders := strings.Split(in, ",")
-			for _, it := range orders {
-				// 去除排序项的前后空格。
-				if it = strings.TrimSpace(it); it != "" && it != "-" {
-					// 检查排序项是否以 '-' 开头，以确定是升序还是降序。
-					if it[0] == '-' {
-						// 如果是降序，移除 '-' 并添加 ' DESC'。
-						orders[x] = it[1:] + " DESC"
-					} else {
-						// 如果是升序，直接添加 ' ASC'。
-						orders[x] = it + " ASC"
-					}
-					x++
-				}
-			}
-			// 如果有有效的排序项，将它们连接成一个字符串并返回。
-			if len(orders) > 0 {
-				return strings.Join(orders[:x], ", ")
-			}
+	// 它接收一个字符串 in，并返回对应的排序列。
+	calc := func(in string) []clause.OrderByColumn {
+		items := ParseOrderBy(in)
+		orders := make([]clause.OrderByColumn, len(items))
+		for i, it := range items {
+			orders[i] = clause.OrderByColumn{Column: orderColumn(it.Column), Desc: it.Desc}
 		}
-		// 如果输入字符串为空，返回空字符串。
-		return ""
+		// 没有有效项时返回空切片。
+		return orders
 	}
 
 	// 使用 calc 函数处理传入的 orderBy 参数。
 	orders := calc(orderBy)
 	// 如果处理结果为空，使用默认的排序参数 def 进行处理。
-	if orders == "" {
+	if len(orders) == 0 {
 		orders = calc(def)
 	}
 
 	// 返回一个 Scope 函数，用于应用排序条件到 gorm.DB 对象。
 	return func(d *gorm.DB) *gorm.DB {
-		// 如果有有效的排序条件，使用 Order 方法添加排序条件到 d。
-		if orders != "" {
-			d = d.Order(orders)
+		// 如果有有效的排序条件，添加排序子句到 d。
+		if len(orders) > 0 {
+			d = d.Clauses(clause.OrderBy{Columns: orders})
 		}
 		// 返回 d。
 		return d
 	}
 }
+
+// orderColumn 把排序项中的列名解析为 clause.Column，未带表名时不附加当前表名。
+func orderColumn(name string) clause.Column {
+	c := column(name)
+	if c.Table == clause.CurrentTable {
+		c.Table = ""
+	}
+	return c
+}
+
+// OrderByNulls 与 OrderBy 相同，但同时指定 NULL 值排在最前（nullsFirst 为 true）还是最后，
+// 使分页结果在不同数据库间保持一致。
+// 方言原生支持时（见 Supports 和 FeatureNullsOrder，如 postgres）生成 `col NULLS FIRST/LAST`；
+// 其他方言（mysql、sqlite 等）在每个排序列之前追加 `CASE WHEN col IS NULL THEN ... END` 排序键来模拟。
+// 排序项作为普通的排序列追加，与 db.Order、OrderBy 等前后组合时都会保留，顺序即添加的顺序。
+//
+// 例如 OrderByNulls("-score", "", false) 在 postgres 上生成 `ORDER BY score DESC NULLS LAST`，
+// 在 mysql 上生成 `ORDER BY CASE WHEN score IS NULL THEN 1 ELSE 0 END, score DESC`。
+func OrderByNulls(orderBy, def string, nullsFirst bool) Scope {
+	items := ParseOrderBy(orderBy)
+	if len(items) == 0 {
+		items = ParseOrderBy(def)
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if len(items) == 0 {
+			return db
+		}
+
+		native := Supports(db, FeatureNullsOrder)
+		columns := make([]clause.OrderByColumn, 0, len(items)*2)
+		for _, it := range items {
+			c := orderColumn(it.Column)
+			if !native {
+				// 模拟时排序键在前，列本身按原样排序
+				key := "CASE WHEN " + db.Statement.Quote(c) + " IS NULL THEN 1 ELSE 0 END"
+				if nullsFirst {
+					key = "CASE WHEN " + db.Statement.Quote(c) + " IS NULL THEN 0 ELSE 1 END"
+				}
+				columns = append(columns,
+					clause.OrderByColumn{Column: clause.Column{Name: key, Raw: true}},
+					clause.OrderByColumn{Column: c, Desc: it.Desc},
+				)
+				continue
+			}
+
+			sql := db.Statement.Quote(c)
+			if it.Desc {
+				sql += " DESC"
+			}
+			if nullsFirst {
+				sql += " NULLS FIRST"
+			} else {
+				sql += " NULLS LAST"
+			}
+			columns = append(columns, clause.OrderByColumn{Column: clause.Column{Name: sql, Raw: true}})
+		}
+		return db.Clauses(clause.OrderBy{Columns: columns})
+	}
+}
+
+// NoSort 创建一个在 mysql 上追加 `ORDER BY NULL` 的查询范围，
+// 用于跳过 mysql 对 GROUP BY 结果的隐式排序，在不需要分组有序时减少一次排序开销。
+// 语句已有排序时不做处理；之后再添加的排序会替换 `ORDER BY NULL`，而不是追加在它后面。
+// 其他方言的 GROUP BY 不隐含排序，同样不做处理。
+func NoSort() Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if dialectName(db) != "mysql" {
+			return db
+		}
+		if _, ok := db.Statement.Clauses["ORDER BY"]; ok {
+			return db
+		}
+		// 以表达式而不是排序列的形式写入，gorm 合并排序子句时只保留排序列，之后的排序会将其替换
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "NULL"}})
+	}
+}
+
+// OrderBySafe 与 OrderBy 相同，但只保留列名在 allowed 中的排序项，其余的会被静默丢弃，
+// 适合直接使用来自请求参数的排序字符串，避免 SQL 注入。
+// 没有剩余的有效排序项时使用 def，def 由调用方提供，不做校验。
+//
+// 例如 OrderBySafe("name,-password", "id", "name", "age") 只按 name 排序。
+func OrderBySafe(orderBy, def string, allowed ...string) Scope {
+	var terms []string
+	for _, it := range ParseOrderBy(orderBy) {
+		if !slices.Contains(allowed, it.Column) {
+			continue
+		}
+		if it.Desc {
+			terms = append(terms, "-"+it.Column)
+		} else {
+			terms = append(terms, it.Column)
+		}
+	}
+	return OrderBy(strings.Join(terms, ","), def)
+}
+
+// OrderByCoalesce 创建一个按 `COALESCE(col, fallback)` 排序的查询范围，
+// 排序时把 NULL 视为 fallback，比 NULLS FIRST/LAST 在各方言间更通用。
+// 列名会加上引号，未带表名时不附加当前表名。
+//
+// 排序项作为普通的排序列追加，与 db.Order、OrderBy 等前后组合时都会保留，顺序即添加的顺序。
+// 为此 fallback 以字面量写入 SQL 而不是绑定参数，支持的类型见 sqlLiteral，其他类型会使语句返回错误。
+//
+// 例如 OrderByCoalesce("priority", 0, true) 生成 `ORDER BY COALESCE(priority, 0) DESC`。
+func OrderByCoalesce(col string, fallback any, desc bool) Scope {
+	c := orderColumn(col)
+	return func(db *gorm.DB) *gorm.DB {
+		lit, ok := sqlLiteral(db, fallback)
+		if !ok {
+			_ = db.AddError(fmt.Errorf("order by coalesce: unsupported fallback %T", fallback))
+			return db
+		}
+		return db.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: "COALESCE(" + db.Statement.Quote(c) + ", " + lit + ")", Raw: true},
+			Desc:   desc,
+		})
+	}
+}
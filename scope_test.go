@@ -0,0 +1,1218 @@
+package gormx
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []OrderItem
+	}{
+		{"", nil},
+		{" , - ,", nil},
+		{"name", []OrderItem{{Column: "name"}}},
+		{"-created_at", []OrderItem{{Column: "created_at", Desc: true}}},
+		{" name , -age,,- id ", []OrderItem{{Column: "name"}, {Column: "age", Desc: true}, {Column: "id", Desc: true}}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseOrderBy(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseOrderBy(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJSONEquals(t *testing.T) {
+	query := func(scope Scope) func(tx *gorm.DB) *gorm.DB {
+		return func(tx *gorm.DB) *gorm.DB {
+			return tx.Table("docs").Scopes(scope).Find(&[]map[string]any{})
+		}
+	}
+
+	tests := []struct {
+		db   *gorm.DB
+		path string
+		want string
+	}{
+		{sqliteDryRun(t), "status", "json_extract(`docs`.`data`, \"$.status\") = \"active\""},
+		{sqliteDryRun(t), "$.profile.status", "json_extract(`docs`.`data`, \"$.profile.status\") = \"active\""},
+		{postgresDryRun(t), "status", `"docs"."data"->>'status' = 'active'`},
+		{postgresDryRun(t), "profile.status", `"docs"."data"->'profile'->>'status' = 'active'`},
+		{mysqlDryRun(t), "status", "JSON_EXTRACT(`docs`.`data`, '$.status') = 'active'"},
+	}
+
+	for _, tt := range tests {
+		sql := toSQL(tt.db, query(JSONEquals("data", tt.path, "active")))
+		if !strings.Contains(sql, tt.want) {
+			t.Errorf("%s %q: got %s, want %s", tt.db.Dialector.Name(), tt.path, sql, tt.want)
+		}
+	}
+
+	// postgres 的 ->> 返回文本，非字符串的值按 JSON 文本绑定
+	for value, want := range map[any]string{1: "1", 2.5: "2.5", true: "true", "x": "x"} {
+		stmt := postgresDryRun(t).Table("docs").Scopes(JSONEquals("data", "n", value)).Find(&[]map[string]any{}).Statement
+		if len(stmt.Vars) != 1 || stmt.Vars[0] != want {
+			t.Errorf("postgres %v: vars = %#v, want %q", value, stmt.Vars, want)
+		}
+	}
+	if sql := toSQL(postgresDryRun(t), query(JSONEquals("data", "n", nil))); !strings.HasSuffix(sql, `"docs"."data"->>'n' IS NULL`) {
+		t.Errorf("postgres nil: got %s", sql)
+	}
+
+	err := postgresDryRun(t).Table("docs").Scopes(JSONEquals("data", "a'; drop table docs; --", 1)).Find(&[]map[string]any{}).Error
+	if err == nil {
+		t.Fatal("expected error for invalid json path")
+	}
+}
+
+type testUser struct {
+	ID     int
+	Name   string
+	Orders []testOrder `gorm:"foreignKey:UserID"`
+}
+
+type testOrder struct {
+	ID     int
+	UserID int
+	Amount int
+	Note   string
+}
+
+// captureSQL 记录 db 上执行的所有查询语句。
+func captureSQL(t *testing.T, db *gorm.DB) *[]string {
+	t.Helper()
+	var sqls []string
+	err := db.Callback().Query().After("gorm:query").Register("test:capture_sql", func(tx *gorm.DB) {
+		sqls = append(sqls, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sqls
+}
+
+func TestPreloadSelect(t *testing.T) {
+	db := newTestDB(t, &testUser{}, &testOrder{})
+	db.Create(&testUser{ID: 1, Name: "a", Orders: []testOrder{{Amount: 10, Note: "x"}, {Amount: 20, Note: "y"}}})
+	sqls := captureSQL(t, db)
+
+	var users []testUser
+	if err := db.Scopes(PreloadSelect("Orders", "amount")).Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 1 || len(users[0].Orders) != 2 {
+		t.Fatalf("unexpected preload result: %+v", users)
+	}
+	for _, o := range users[0].Orders {
+		if o.Amount == 0 || o.UserID != 1 || o.ID != 0 || o.Note != "" {
+			t.Fatalf("unexpected order columns: %+v", o)
+		}
+	}
+
+	want := "SELECT `amount`,`user_id` FROM `test_orders`"
+	if !slices.ContainsFunc(*sqls, func(s string) bool { return strings.HasPrefix(s, want) }) {
+		t.Fatalf("got %q, want a query with prefix %s", *sqls, want)
+	}
+}
+
+func TestPreloads(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	tx := Preloads("Orders", "Profile.Avatar")(db.Model(&testUser{}))
+	for _, name := range []string{"Orders", "Profile.Avatar"} {
+		if _, ok := tx.Statement.Preloads[name]; !ok {
+			t.Errorf("preload %s not registered: %v", name, tx.Statement.Preloads)
+		}
+	}
+
+	if tx = Preloads()(db.Model(&testUser{})); len(tx.Statement.Preloads) != 0 {
+		t.Errorf("unexpected preloads: %v", tx.Statement.Preloads)
+	}
+}
+
+type testPost struct {
+	ID        int
+	DeletedAt gorm.DeletedAt
+	Comments  []testComment `gorm:"foreignKey:PostID"`
+}
+
+type testComment struct {
+	ID        int
+	PostID    int
+	DeletedAt gorm.DeletedAt
+}
+
+func TestPreloadUnscoped(t *testing.T) {
+	db := newTestDB(t, &testPost{}, &testComment{})
+	db.Create(&[]testPost{
+		{ID: 1, Comments: []testComment{{ID: 1}, {ID: 2}}},
+		{ID: 2, Comments: []testComment{{ID: 3}}},
+	})
+	db.Delete(&testComment{}, 2)
+	db.Delete(&testPost{}, 2)
+
+	var posts []testPost
+	if err := db.Scopes(PreloadUnscoped("Comments")).Find(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("soft-deleted post returned: %+v", posts)
+	}
+	if len(posts[0].Comments) != 2 {
+		t.Fatalf("expected soft-deleted comment to be preloaded, got %+v", posts[0].Comments)
+	}
+
+	posts = nil
+	if err := db.Preload("Comments").Find(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || len(posts[0].Comments) != 1 {
+		t.Fatalf("plain preload should skip soft-deleted comments: %+v", posts)
+	}
+}
+
+func TestBind(t *testing.T) {
+	between := func(db *gorm.DB, lo, hi int) *gorm.DB {
+		return db.Where("sort BETWEEN ? AND ?", lo, hi)
+	}
+
+	sql := toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&ZZ{}).Scopes(Bind2(between, 1, 9)).Find(&[]ZZ{})
+	})
+
+	if want := "WHERE sort BETWEEN 1 AND 9"; !strings.Contains(sql, want) {
+		t.Fatalf("got %s, want %s", sql, want)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+
+	stmt := db.Scopes(DryRun()).Where("sort > ?", 3).Find(&[]ZZ{}).Statement
+	if want := "SELECT * FROM `zzs` WHERE sort > ?"; stmt.SQL.String() != want {
+		t.Fatalf("got %s, want %s", stmt.SQL.String(), want)
+	}
+	if len(stmt.Vars) != 1 || stmt.Vars[0] != 3 {
+		t.Fatalf("unexpected vars: %v", stmt.Vars)
+	}
+
+	// DryRun 模式下的写入不应落库。
+	db.Scopes(DryRun()).Create(&ZZ{ID: 1})
+	var count int64
+	db.Model(&ZZ{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("dry run wrote %d rows", count)
+	}
+}
+
+func TestColumnCompare(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	sql := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&ZZ{}).Scopes(ColumnCompare("updated_at", ">", "zzs.sort")).Find(&[]ZZ{})
+	})
+	if want := "WHERE `zzs`.`updated_at` > `zzs`.`sort`"; !strings.Contains(sql, want) {
+		t.Fatalf("got %s, want %s", sql, want)
+	}
+
+	if err := db.Model(&ZZ{}).Scopes(ColumnCompare("a", "; DROP", "b")).Find(&[]ZZ{}).Error; err == nil {
+		t.Fatal("expected error for invalid operator")
+	}
+}
+
+func TestWhereFunc(t *testing.T) {
+	db := postgresDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{WhereFunc("lower(?)", "=", "a@b.c", "email"), `SELECT * FROM "users" WHERE lower("users"."email") = 'a@b.c'`},
+		{WhereFunc("coalesce(?, ?)", ">=", 3, "u.score", "bonus"), `SELECT * FROM "users" WHERE coalesce("u"."score", "users"."bonus") >= 3`},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Table("users").Scopes(tt.scope).Find(&[]map[string]any{}) })
+		if got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+
+	for _, scope := range []Scope{
+		WhereFunc("lower(?)", "LIKE", "a", "email"),
+		WhereFunc("lower(?)", "=", "a"),
+	} {
+		if err := db.Table("users").Scopes(scope).Find(&[]map[string]any{}).Error; err == nil {
+			t.Error("expected error")
+		}
+	}
+}
+
+func TestHavingAlias(t *testing.T) {
+	find := func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).
+			Select("user_id, COALESCE(SUM(amount), 0) AS total").
+			Group("user_id").
+			Scopes(HavingAlias("total", ">", 100)).
+			Find(&[]map[string]any{})
+	}
+
+	if got, want := toSQL(mysqlDryRun(t), find), "SELECT user_id, COALESCE(SUM(amount), 0) AS total FROM `test_orders` GROUP BY `user_id` HAVING `total` > 100"; got != want {
+		t.Errorf("mysql:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(postgresDryRun(t), find), `SELECT user_id, COALESCE(SUM(amount), 0) AS total FROM "test_orders" GROUP BY "user_id" HAVING COALESCE(SUM(amount), 0) > 100`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+
+	err := postgresDryRun(t).Model(&testOrder{}).Select("user_id").Group("user_id").Scopes(HavingAlias("total", ">", 1)).Find(&[]map[string]any{}).Error
+	if err == nil {
+		t.Error("expected error for unknown alias")
+	}
+
+	db := newTestDB(t, &testOrder{})
+	db.Create(&[]testOrder{{UserID: 1, Amount: 60}, {UserID: 1, Amount: 60}, {UserID: 2, Amount: 10}})
+	if tx := find(db); tx.Error != nil || tx.RowsAffected != 1 {
+		t.Fatalf("rows = %d, err = %v", tx.RowsAffected, tx.Error)
+	}
+}
+
+func TestUpsertOn(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	sql := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(UpsertOn([]string{"id"}, "sort")).Create(&ZZ{ID: 1, Sort: 2})
+	})
+	if want := "ON CONFLICT (`id`) DO UPDATE SET `sort`=`excluded`.`sort`"; !strings.Contains(sql, want) {
+		t.Fatalf("got %s, want %s", sql, want)
+	}
+
+	sql = toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(UpsertOn([]string{"id"})).Create(&ZZ{ID: 1, Sort: 2})
+	})
+	if want := `ON CONFLICT ("id") DO UPDATE SET "updated_at"=`; !strings.Contains(sql, want) || !strings.Contains(sql, `"sort"="excluded"."sort"`) {
+		t.Fatalf("got %s, want %s", sql, want)
+	}
+}
+
+func TestUpsertOnWhere(t *testing.T) {
+	upsert := func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(UpsertOnWhere([]string{"sort"}, gorm.Expr("deleted_at IS NULL"), "sort")).Create(&ZZ{ID: 1, Sort: 2})
+	}
+
+	sql := toSQL(postgresDryRun(t), upsert)
+	// gorm 在冲突列和 WHERE 之间输出两个空格。
+	if want := `ON CONFLICT ("sort")  WHERE deleted_at IS NULL DO UPDATE SET "sort"="excluded"."sort"`; !strings.Contains(sql, want) {
+		t.Fatalf("got %s, want %s", sql, want)
+	}
+
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	sql = toSQL(sqliteDryRun(t), upsert)
+	if want := "ON CONFLICT (`sort`) DO UPDATE SET `sort`=`excluded`.`sort`"; !strings.Contains(sql, want) {
+		t.Fatalf("got %s, want %s", sql, want)
+	}
+	if !strings.Contains(buf.String(), "only supported by postgres") {
+		t.Fatalf("expected a warning, got %q", buf.String())
+	}
+}
+
+func TestFromSubquery(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	sql := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		paid := tx.Model(&testOrder{}).Where("amount > ?", 10)
+		return tx.Scopes(FromSubquery(paid, "p"), Like("note", "x")).Order("id").Find(&[]testOrder{})
+	})
+
+	want := "SELECT * FROM (SELECT * FROM `test_orders` WHERE amount > 10) AS `p` WHERE `p`.`note` LIKE \"%x%\" ORDER BY id"
+	if sql != want {
+		t.Fatalf("got  %s\nwant %s", sql, want)
+	}
+
+	live := newTestDB(t, &testOrder{})
+	live.Create(&[]testOrder{{Amount: 5, Note: "x"}, {Amount: 20, Note: "x"}, {Amount: 30, Note: "y"}})
+	var orders []testOrder
+	paid := live.Model(&testOrder{}).Where("amount > ?", 10)
+	if err := live.Scopes(FromSubquery(paid, "p"), Like("note", "x")).Find(&orders).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].Amount != 20 {
+		t.Fatalf("unexpected rows: %+v", orders)
+	}
+}
+
+func TestSelectSubquery(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	sql := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		orders := tx.Model(&testOrder{}).Select("COUNT(*)").Where("test_orders.user_id = test_users.id")
+		paid := tx.Model(&testOrder{}).Select("COALESCE(SUM(amount), 0)").Where("test_orders.user_id = test_users.id AND amount > ?", 10)
+		return tx.Model(&testUser{}).
+			Scopes(SelectSubquery("order_count", orders), SelectSubquery("paid", paid)).
+			Where("name = ?", "x").
+			Find(&[]map[string]any{})
+	})
+
+	want := "SELECT `test_users`.*, (SELECT COUNT(*) FROM `test_orders` WHERE test_orders.user_id = test_users.id) AS `order_count`, " +
+		"(SELECT COALESCE(SUM(amount), 0) FROM `test_orders` WHERE test_orders.user_id = test_users.id AND amount > 10) AS `paid` " +
+		"FROM `test_users` WHERE name = \"x\""
+	if sql != want {
+		t.Fatalf("got  %s\nwant %s", sql, want)
+	}
+
+	sql = toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		orders := tx.Model(&testOrder{}).Select("COUNT(*)").Where("test_orders.user_id = test_users.id")
+		return tx.Model(&testUser{}).Select("id", "name").Scopes(SelectSubquery("order_count", orders)).Find(&[]map[string]any{})
+	})
+
+	want = "SELECT `test_users`.`id`, `test_users`.`name`, (SELECT COUNT(*) FROM `test_orders` WHERE test_orders.user_id = test_users.id) AS `order_count` FROM `test_users`"
+	if sql != want {
+		t.Fatalf("got  %s\nwant %s", sql, want)
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2024, 3, 14, 15, 30, 0, 0, loc) // 周四
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, loc) }
+
+	tests := []struct {
+		window     string
+		start, end time.Time
+	}{
+		{"today", day(3, 14), day(3, 15)},
+		{"yesterday", day(3, 13), day(3, 14)},
+		{"this_week", day(3, 11), day(3, 18)},
+		{"this_month", day(3, 1), day(4, 1)},
+		{"last_7d", day(3, 8), day(3, 15)},
+		{"last_30d", day(2, 14), day(3, 15)},
+	}
+	for _, tt := range tests {
+		start, end, ok := timeWindow(tt.window, now)
+		if !ok || !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: got [%v, %v) %v, want [%v, %v)", tt.window, start, end, ok, tt.start, tt.end)
+		}
+	}
+
+	db := sqliteDryRun(t)
+	if err := db.Table("zzs").Scopes(TimeWindow("created_at", "today", loc)).Find(&[]map[string]any{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table("zzs").Scopes(TimeWindow("created_at", "next_year", loc)).Find(&[]map[string]any{}).Error; err == nil {
+		t.Fatal("expected error for unknown window")
+	}
+}
+
+func TestReturning(t *testing.T) {
+	del := func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(Returning("id", "created_at")).Where("id = ?", 1).Delete(&testOrder{})
+	}
+
+	if got, want := toSQL(postgresDryRun(t), del), `DELETE FROM "test_orders" WHERE id = 1 RETURNING "id","created_at"`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(mysqlDryRun(t), del), "DELETE FROM `test_orders` WHERE id = 1"; got != want {
+		t.Errorf("mysql:\n got  %s\n want %s", got, want)
+	}
+
+	all := toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(Returning()).Where("id = ?", 1).Delete(&testOrder{})
+	})
+	if !strings.HasSuffix(all, "RETURNING *") {
+		t.Errorf("empty columns: %s", all)
+	}
+}
+
+// renamedDialector 以其他方言的名称包装一个方言，用于测试依赖方言名称的查询范围。
+type renamedDialector struct {
+	gorm.Dialector
+	name string
+}
+
+func (d renamedDialector) Name() string { return d.name }
+
+func TestSQLServerHint(t *testing.T) {
+	mssql := dryRun(t, renamedDialector{drivers["sqlite"](":memory:"), "sqlserver"})
+	scopes := []func(*gorm.DB) *gorm.DB{SQLServerHint("RECOMPILE"), SQLServerHint("MAXDOP 1")}
+
+	sql := toSQL(mssql, func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(scopes...).Where("id = ?", 1).Find(&[]testOrder{})
+	})
+	if want := "SELECT * FROM `test_orders` WHERE id = 1 OPTION (RECOMPILE, MAXDOP 1)"; sql != want {
+		t.Errorf("sqlserver:\n got  %s\n want %s", sql, want)
+	}
+
+	sql = toSQL(mssql, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).Scopes(scopes...).Where("id = ?", 1).Update("note", "x")
+	})
+	if strings.Contains(sql, "OPTION") {
+		t.Errorf("update should not carry the hint: %s", sql)
+	}
+
+	sql = toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(scopes...).Find(&[]testOrder{})
+	})
+	if strings.Contains(sql, "OPTION") {
+		t.Errorf("sqlite should not carry the hint: %s", sql)
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	db := sqliteDryRun(t)
+
+	sql := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(SelectColumns("user_id", "`amount`")).Create(&testOrder{UserID: 1, Amount: 2, Note: "ignored"})
+	})
+	if want := "INSERT INTO `test_orders` (`user_id`,`amount`) VALUES (1,2) RETURNING `id`"; sql != want {
+		t.Errorf("create:\n got  %s\n want %s", sql, want)
+	}
+
+	sql = toSQL(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(SelectColumns("note")).Where("id = ?", 1).Updates(&testOrder{Amount: 5})
+	})
+	if want := "UPDATE `test_orders` SET `note`=\"\" WHERE id = 1"; sql != want {
+		t.Errorf("update:\n got  %s\n want %s", sql, want)
+	}
+}
+
+func TestQueryFields(t *testing.T) {
+	sql := toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(QueryFields()).Where("id = ?", 1).Find(&[]testOrder{})
+	})
+	want := "SELECT `test_orders`.`id`,`test_orders`.`user_id`,`test_orders`.`amount`,`test_orders`.`note` FROM `test_orders` WHERE id = 1"
+	if sql != want {
+		t.Errorf("got  %s\nwant %s", sql, want)
+	}
+}
+
+func TestForUpdateNoWait(t *testing.T) {
+	find := func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(ForUpdateNoWait()).Where("id = ?", 1).Find(&[]testOrder{})
+	}
+
+	if got, want := toSQL(postgresDryRun(t), find), `SELECT * FROM "test_orders" WHERE id = 1 FOR UPDATE NOWAIT`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(dryRun(t, renamedDialector{postgres.Open("host=localhost"), "other"}), find), `SELECT * FROM "test_orders" WHERE id = 1 FOR UPDATE`; got != want {
+		t.Errorf("fallback:\n got  %s\n want %s", got, want)
+	}
+}
+
+func TestForUpdateOf(t *testing.T) {
+	find := func(tables ...string) func(tx *gorm.DB) *gorm.DB {
+		return func(tx *gorm.DB) *gorm.DB {
+			return tx.Table("users").Joins("JOIN orders ON orders.user_id = users.id").Scopes(ForUpdateOf(tables...)).Find(&[]map[string]any{})
+		}
+	}
+
+	pg := postgresDryRun(t)
+	if got, want := toSQL(pg, find("users")), `SELECT * FROM "users" JOIN orders ON orders.user_id = users.id FOR UPDATE OF "users"`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(pg, find("users", "orders")), `SELECT * FROM "users" JOIN orders ON orders.user_id = users.id FOR UPDATE OF "users", "orders"`; got != want {
+		t.Errorf("postgres multiple:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(mysqlDryRun(t), find("users")), "SELECT * FROM `users` JOIN orders ON orders.user_id = users.id FOR UPDATE OF `users`"; got != want {
+		t.Errorf("mysql:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(dryRun(t, renamedDialector{postgres.Open("host=localhost"), "other"}), find("users")), `SELECT * FROM "users" JOIN orders ON orders.user_id = users.id FOR UPDATE`; got != want {
+		t.Errorf("fallback:\n got  %s\n want %s", got, want)
+	}
+}
+
+func TestOrderByCoalesce(t *testing.T) {
+	sql := toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id").Scopes(OrderByCoalesce("priority", 0, true), OrderByCoalesce("t.rank", -1, false)).Find(&[]testOrder{})
+	})
+	want := `SELECT * FROM "test_orders" ORDER BY id,COALESCE("priority", 0) DESC,COALESCE("t"."rank", -1)`
+	if sql != want {
+		t.Errorf("got  %s\nwant %s", sql, want)
+	}
+
+	// 之后再添加的排序不会覆盖该排序
+	sql = toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(OrderByCoalesce("priority", 0, true), func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }, OrderBy("-amount", "")).Find(&[]testOrder{})
+	})
+	want = `SELECT * FROM "test_orders" ORDER BY COALESCE("priority", 0) DESC,id,"amount" DESC`
+	if sql != want {
+		t.Errorf("chained:\n got  %s\n want %s", sql, want)
+	}
+
+	for fallback, want := range map[any]string{
+		"it's":  `COALESCE("note", 'it''s')`,
+		1.5:     `COALESCE("note", 1.5)`,
+		true:    `COALESCE("note", TRUE)`,
+		uint(7): `COALESCE("note", 7)`,
+	} {
+		sql := toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(OrderByCoalesce("note", fallback, false)).Find(&[]testOrder{})
+		})
+		if !strings.HasSuffix(sql, want) {
+			t.Errorf("fallback %v: got %s, want suffix %s", fallback, sql, want)
+		}
+	}
+	if sql := toSQL(mysqlDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(OrderByCoalesce("note", `a\'b`, false)).Find(&[]testOrder{})
+	}); !strings.HasSuffix(sql, "COALESCE(`note`, 'a\\\\''b')") {
+		t.Errorf("mysql escaping: %s", sql)
+	}
+	if err := postgresDryRun(t).Scopes(OrderByCoalesce("note", struct{}{}, false)).Find(&[]testOrder{}).Error; err == nil {
+		t.Error("expected error for unsupported fallback")
+	}
+
+	db := newTestDB(t, &testOrder{})
+	db.Create(&[]testOrder{{ID: 1, Note: "b"}, {ID: 2}, {ID: 3, Note: "a"}})
+	db.Exec("UPDATE test_orders SET note = NULL WHERE id = 2")
+	var ids []int
+	if err := db.Model(&testOrder{}).Scopes(OrderByCoalesce("note", "c", false)).Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []int{3, 1, 2}) {
+		t.Errorf("got %v, want [3 1 2]", ids)
+	}
+}
+
+func TestDefaultLimit(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scopes []func(*gorm.DB) *gorm.DB
+		want   string
+	}{
+		{[]func(*gorm.DB) *gorm.DB{Paging(2, 20, 0), DefaultLimit(100)}, "SELECT * FROM `test_orders` LIMIT 20 OFFSET 20"},
+		{[]func(*gorm.DB) *gorm.DB{DefaultLimit(100)}, "SELECT * FROM `test_orders` LIMIT 100"},
+		{[]func(*gorm.DB) *gorm.DB{func(tx *gorm.DB) *gorm.DB { return tx.Offset(5) }, DefaultLimit(100)}, "SELECT * FROM `test_orders` LIMIT 100 OFFSET 5"},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scopes...).Find(&[]testOrder{}) })
+		if got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestRowNumber(t *testing.T) {
+	sql := toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).Scopes(RowNumber("rn", "user_id", "-amount,id")).Find(&[]map[string]any{})
+	})
+	want := `SELECT "test_orders".*, ROW_NUMBER() OVER (PARTITION BY "test_orders"."user_id" ORDER BY "test_orders"."amount" DESC, "test_orders"."id") AS "rn" FROM "test_orders"`
+	if sql != want {
+		t.Errorf("got  %s\nwant %s", sql, want)
+	}
+
+	sql = toSQL(postgresDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).Select("id").Scopes(RowNumber("rn", "", "-id")).Find(&[]map[string]any{})
+	})
+	want = `SELECT "test_orders"."id", ROW_NUMBER() OVER (ORDER BY "test_orders"."id" DESC) AS "rn" FROM "test_orders"`
+	if sql != want {
+		t.Errorf("got  %s\nwant %s", sql, want)
+	}
+
+	other := dryRun(t, renamedDialector{drivers["sqlite"](":memory:"), "unknown"})
+	if err := other.Model(&testOrder{}).Scopes(RowNumber("rn", "user_id", "id")).Find(&[]map[string]any{}).Error; err == nil {
+		t.Error("expected error on dialect without window functions")
+	}
+}
+
+func TestILike(t *testing.T) {
+	find := func(tx *gorm.DB) *gorm.DB { return tx.Scopes(ILike("test_orders.note", "Abc")).Find(&[]testOrder{}) }
+
+	if got, want := toSQL(sqliteDryRun(t), find), "SELECT * FROM `test_orders` WHERE LOWER(`test_orders`.`note`) LIKE LOWER(\"%Abc%\")"; got != want {
+		t.Errorf("sqlite:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(postgresDryRun(t), find), `SELECT * FROM "test_orders" WHERE "test_orders"."note" ILIKE '%Abc%'`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+
+	db := newTestDB(t, &testOrder{})
+	db.Create(&[]testOrder{{Note: "Hello World"}, {Note: "other"}})
+	var orders []testOrder
+	if err := db.Scopes(ILike("note", "WORLD")).Find(&orders).Error; err != nil || len(orders) != 1 {
+		t.Fatalf("orders = %+v, err = %v", orders, err)
+	}
+}
+
+func TestInLarge(t *testing.T) {
+	old := inLargeThreshold
+	inLargeThreshold = 5
+	t.Cleanup(func() { inLargeThreshold = old })
+
+	small, large := []int{1, 2}, []int{1, 2, 3, 4, 5, 6}
+	find := func(values []int) func(tx *gorm.DB) *gorm.DB {
+		return func(tx *gorm.DB) *gorm.DB { return tx.Scopes(InLarge("id", values)).Find(&[]ZZ{}) }
+	}
+
+	db := sqliteDryRun(t)
+	if got, want := toSQL(db, find(small)), "SELECT * FROM `zzs` WHERE `zzs`.`id` IN (1,2)"; got != want {
+		t.Errorf("small:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(db, find(large)), "SELECT * FROM `zzs` WHERE `zzs`.`id` IN (SELECT value FROM json_each(\"[1,2,3,4,5,6]\"))"; got != want {
+		t.Errorf("large:\n got  %s\n want %s", got, want)
+	}
+	pg := postgresDryRun(t).Scopes(InLarge("id", large)).Find(&[]ZZ{}).Statement
+	if got, want := pg.SQL.String(), `SELECT * FROM "zzs" WHERE "zzs"."id" = ANY($1)`; got != want || len(pg.Vars) != 1 {
+		t.Errorf("postgres:\n got  %s %v\n want %s", got, pg.Vars, want)
+	}
+	if got := toSQL(mysqlDryRun(t), find(large)); !strings.Contains(got, "IN (1,2,3,4,5,6)") {
+		t.Errorf("mysql should use a plain IN list: %s", got)
+	}
+
+	live := newTestDB(t, &ZZ{})
+	seedSort(t, live, 10)
+	var rows []ZZ
+	if err := live.Scopes(InLarge("id", []int{2, 4, 6, 8, 10, 12})).Find(&rows).Error; err != nil || len(rows) != 5 {
+		t.Fatalf("rows = %d, err = %v, want 5", len(rows), err)
+	}
+}
+
+func TestLike(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{Like("test_orders.note", "a"), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` LIKE \"%a%\""},
+		{NotLike("note", "a_b"), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` NOT LIKE \"%a_b%\""},
+		{Prefix("note", "a"), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` LIKE \"a%\""},
+		{Suffix("note", "a"), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` LIKE \"%a\""},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope).Find(&[]testOrder{}) }); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestForUpdateOutsideTransaction(t *testing.T) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	db := newTestDB(t, &testOrder{})
+
+	if err := db.Scopes(ForUpdate()).Find(&[]testOrder{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "outside of a transaction") {
+		t.Fatalf("expected a warning, got %q", buf.String())
+	}
+
+	buf.Reset()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Scopes(ForUpdate(), ForUpdateNoWait()).Find(&[]testOrder{}).Error
+	})
+	if err != nil || buf.Len() != 0 {
+		t.Fatalf("unexpected warning inside transaction: %q, err = %v", buf.String(), err)
+	}
+
+	SetLockStrict(true)
+	t.Cleanup(func() { SetLockStrict(false) })
+	if err = db.Scopes(ForUpdateNoWait()).Find(&[]testOrder{}).Error; err == nil {
+		t.Fatal("expected error in strict mode")
+	}
+}
+
+func TestIn(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{In("zzs.id", []int{1, 2}), "SELECT * FROM `zzs` WHERE `zzs`.`id` IN (1,2)"},
+		{In("id", []int{}), "SELECT * FROM `zzs` WHERE 1 = 0"},
+		{NotIn("id", []string{"a"}), "SELECT * FROM `zzs` WHERE `zzs`.`id` NOT IN (\"a\")"},
+		{NotIn[int]("id", nil), "SELECT * FROM `zzs` WHERE 1 = 1"},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope).Find(&[]ZZ{}) }); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{Between("sort", 1, 5), "SELECT * FROM `zzs` WHERE `zzs`.`sort` BETWEEN 1 AND 5"},
+		{Between("sort", 5, 1), "SELECT * FROM `zzs` WHERE `zzs`.`sort` BETWEEN 1 AND 5"},
+		{Gte("sort", 1), "SELECT * FROM `zzs` WHERE `zzs`.`sort` >= 1"},
+		{Lte("zzs.sort", 1), "SELECT * FROM `zzs` WHERE `zzs`.`sort` <= 1"},
+		{Gt("sort", 1), "SELECT * FROM `zzs` WHERE `zzs`.`sort` > 1"},
+		{Lt("sort", 1), "SELECT * FROM `zzs` WHERE `zzs`.`sort` < 1"},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope).Find(&[]ZZ{}) }); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestWhereIf(t *testing.T) {
+	filter := func(q string, min int) Scope {
+		return Scopes(
+			WhereIf(q != "", Like("note", q)),
+			WhereIf(min > 0, Gte("amount", min)),
+			nil,
+		)
+	}
+
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{filter("", 0), "SELECT * FROM `test_orders`"},
+		{filter("a", 0), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` LIKE \"%a%\""},
+		{filter("a", 3), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` LIKE \"%a%\" AND `test_orders`.`amount` >= 3"},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope).Find(&[]testOrder{}) }); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestOr(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{
+			Or(Like("note", "a"), Gte("amount", 3)),
+			"SELECT * FROM `test_orders` WHERE `test_orders`.`id` > 1 AND (`test_orders`.`note` LIKE \"%a%\" OR `test_orders`.`amount` >= 3)",
+		},
+		{
+			Or(Like("note", "a"), Scopes(Gte("amount", 3), Lte("amount", 5))),
+			"SELECT * FROM `test_orders` WHERE `test_orders`.`id` > 1 AND (`test_orders`.`note` LIKE \"%a%\" OR (`test_orders`.`amount` >= 3 AND `test_orders`.`amount` <= 5))",
+		},
+		{
+			Or(WhereIf(false, Like("note", "a")), Gte("amount", 3)),
+			"SELECT * FROM `test_orders` WHERE `test_orders`.`id` > 1 AND `test_orders`.`amount` >= 3",
+		},
+		{Or(), "SELECT * FROM `test_orders` WHERE `test_orders`.`id` > 1"},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(Gt("id", 1), tt.scope).Find(&[]testOrder{})
+		})
+		if got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestIsNull(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{IsNull("note"), "SELECT * FROM `test_orders` WHERE `test_orders`.`note` IS NULL"},
+		{IsNotNull("o.note"), "SELECT * FROM `test_orders` WHERE `o`.`note` IS NOT NULL"},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope).Find(&[]testOrder{}) }); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestSetMaxInSize(t *testing.T) {
+	SetMaxInSize(2)
+	t.Cleanup(func() { SetMaxInSize(0) })
+
+	db := sqliteDryRun(t)
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{In("id", []int{1, 2}), "SELECT * FROM `test_orders` WHERE `test_orders`.`id` IN (1,2)"},
+		{In("id", []int{1, 2, 3, 4, 5}), "SELECT * FROM `test_orders` WHERE (`test_orders`.`id` IN (1,2) OR `test_orders`.`id` IN (3,4) OR `test_orders`.`id` IN (5))"},
+		{NotIn("id", []int{1, 2, 3}), "SELECT * FROM `test_orders` WHERE (`test_orders`.`id` NOT IN (1,2) AND `test_orders`.`id` NOT IN (3))"},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope, Gt("amount", 0)).Find(&[]testOrder{}) })
+		if want := tt.want + " AND `test_orders`.`amount` > 0"; got != want {
+			t.Errorf("got  %s\nwant %s", got, want)
+		}
+	}
+
+	live := newTestDB(t, &testOrder{})
+	for i := 1; i <= 6; i++ {
+		live.Create(&testOrder{ID: i, Amount: i})
+	}
+	var in, notIn int64
+	live.Model(&testOrder{}).Scopes(In("id", []int{1, 2, 3, 5, 9})).Count(&in)
+	live.Model(&testOrder{}).Scopes(NotIn("id", []int{1, 2, 3, 5, 9})).Count(&notIn)
+	if in != 4 || notIn != 2 {
+		t.Fatalf("in = %d, not in = %d", in, notIn)
+	}
+}
+
+func TestStaticIn(t *testing.T) {
+	tests := []struct {
+		db   *gorm.DB
+		want string
+	}{
+		{sqliteDryRun(t), "SELECT * FROM `test_orders` WHERE `test_orders`.`id` IN (SELECT value FROM json_each(?))"},
+		{mysqlDryRun(t), "SELECT * FROM `test_orders` WHERE `test_orders`.`id` MEMBER OF(CAST(? AS JSON))"},
+		{postgresDryRun(t), `SELECT * FROM "test_orders" WHERE "test_orders"."id" = ANY($1)`},
+	}
+	for _, tt := range tests {
+		for _, ids := range [][]int{{1, 2}, {1, 2, 3, 4, 5}} {
+			stmt := tt.db.Scopes(StaticIn("id", ids)).Find(&[]testOrder{}).Statement
+			if got := stmt.SQL.String(); got != tt.want {
+				t.Errorf("%s %v:\n got  %s\n want %s", tt.db.Dialector.Name(), ids, got, tt.want)
+			}
+		}
+	}
+
+	if got, want := pgArray([]string{"a", `b"c`, `d\e`}), `{"a","b\"c","d\\e"}`; got != want {
+		t.Errorf("pgArray = %s, want %s", got, want)
+	}
+
+	live := newTestDB(t, &testOrder{})
+	for i := 1; i <= 6; i++ {
+		live.Create(&testOrder{ID: i, Amount: i})
+	}
+	var in, empty int64
+	live.Model(&testOrder{}).Scopes(StaticIn("id", []int{1, 2, 3, 5, 9})).Count(&in)
+	live.Model(&testOrder{}).Scopes(StaticIn("id", []int{})).Count(&empty)
+	if in != 4 || empty != 0 {
+		t.Fatalf("in = %d, empty = %d", in, empty)
+	}
+}
+
+func TestInEnum(t *testing.T) {
+	db := newTestDB(t, &testOrder{})
+	db.Create(&[]testOrder{{Note: "paid"}, {Note: "paid"}, {Note: "refunded"}})
+
+	statuses := []string{"paid", "refunded", "pending"}
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"paid", 2},
+		{"pending", 0},
+		{"paid' OR 1=1 --", 0},
+	}
+	for _, tt := range tests {
+		var orders []testOrder
+		if err := db.Scopes(InEnum("note", tt.value, statuses...)).Find(&orders).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != tt.want {
+			t.Errorf("InEnum(%q) got %d rows, want %d", tt.value, len(orders), tt.want)
+		}
+	}
+
+	got := toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(InEnum("note", "bad", statuses...)).Find(&[]testOrder{})
+	})
+	if want := "SELECT * FROM `test_orders` WHERE 1 = 0"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestKeysetPaging(t *testing.T) {
+	db := sqliteDryRun(t)
+	var nilID *int
+	tests := []struct {
+		scope Scope
+		want  string
+	}{
+		{KeysetPaging("id", 0, 10, false), "SELECT * FROM `test_orders` ORDER BY `test_orders`.`id` LIMIT 10"},
+		{KeysetPaging("id", nilID, 10, true), "SELECT * FROM `test_orders` ORDER BY `test_orders`.`id` DESC LIMIT 10"},
+		{KeysetPaging("id", 20, 10, false), "SELECT * FROM `test_orders` WHERE `test_orders`.`id` > 20 ORDER BY `test_orders`.`id` LIMIT 10"},
+		{KeysetPaging("o.id", 20, 10, true), "SELECT * FROM `test_orders` WHERE `o`.`id` < 20 ORDER BY `o`.`id` DESC LIMIT 10"},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(tt.scope).Find(&[]testOrder{}) }); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+
+	live := newTestDB(t, &testOrder{})
+	for i := 1; i <= 5; i++ {
+		live.Create(&testOrder{ID: i})
+	}
+	var page []testOrder
+	if err := live.Scopes(KeysetPaging("id", 4, 2, true)).Find(&page).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].ID != 3 || page[1].ID != 2 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	db := postgresDryRun(t)
+	tests := []struct {
+		orderBy, def string
+		want         string
+	}{
+		{"MixedCase,-order", "", `SELECT * FROM "test_orders" ORDER BY "MixedCase","order" DESC`},
+		{"-users.created_at, id", "", `SELECT * FROM "test_orders" ORDER BY "users"."created_at" DESC,"id"`},
+		{"", "-id", `SELECT * FROM "test_orders" ORDER BY "id" DESC`},
+		{"", "", `SELECT * FROM "test_orders"`},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB { return tx.Scopes(OrderBy(tt.orderBy, tt.def)).Find(&[]testOrder{}) })
+		if got != tt.want {
+			t.Errorf("OrderBy(%q, %q)\ngot  %s\nwant %s", tt.orderBy, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestOrderByNulls(t *testing.T) {
+	find := func(orderBy string, nullsFirst bool) func(tx *gorm.DB) *gorm.DB {
+		return func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(OrderByNulls(orderBy, "id", nullsFirst)).Find(&[]testOrder{})
+		}
+	}
+
+	if got, want := toSQL(postgresDryRun(t), find("-note,t.amount", false)), `SELECT * FROM "test_orders" ORDER BY "note" DESC NULLS LAST,"t"."amount" NULLS LAST`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(mysqlDryRun(t), find("", true)), "SELECT * FROM `test_orders` ORDER BY CASE WHEN `id` IS NULL THEN 0 ELSE 1 END,`id`"; got != want {
+		t.Errorf("mysql:\n got  %s\n want %s", got, want)
+	}
+
+	// 前后再添加的排序都不会覆盖该排序
+	chained := func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("user_id").Scopes(OrderByNulls("-note", "", false), func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).Find(&[]testOrder{})
+	}
+	if got, want := toSQL(postgresDryRun(t), chained), `SELECT * FROM "test_orders" ORDER BY user_id,"note" DESC NULLS LAST,id`; got != want {
+		t.Errorf("postgres chained:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(mysqlDryRun(t), chained), "SELECT * FROM `test_orders` ORDER BY user_id,CASE WHEN `note` IS NULL THEN 1 ELSE 0 END,`note` DESC,id"; got != want {
+		t.Errorf("mysql chained:\n got  %s\n want %s", got, want)
+	}
+
+	type row struct {
+		ID   int
+		Rank *int
+	}
+	db := newTestDB(t, &row{})
+	one, two := 1, 2
+	db.Create(&[]row{{ID: 1, Rank: &two}, {ID: 2}, {ID: 3, Rank: &one}})
+	for _, tt := range []struct {
+		nullsFirst bool
+		want       []int
+	}{
+		{true, []int{2, 3, 1}},
+		{false, []int{3, 1, 2}},
+	} {
+		var ids []int
+		if err := db.Model(&row{}).Scopes(OrderByNulls("rank", "", tt.nullsFirst)).Pluck("id", &ids).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("nullsFirst=%v: got %v, want %v", tt.nullsFirst, ids, tt.want)
+		}
+	}
+}
+
+func TestNoSort(t *testing.T) {
+	find := func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).Select("user_id, COUNT(*)").Group("user_id").Scopes(NoSort()).Find(&[]map[string]any{})
+	}
+
+	if got, want := toSQL(mysqlDryRun(t), find), "SELECT user_id, COUNT(*) FROM `test_orders` GROUP BY `user_id` ORDER BY NULL"; got != want {
+		t.Errorf("mysql:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(sqliteDryRun(t), find), "SELECT user_id, COUNT(*) FROM `test_orders` GROUP BY `user_id`"; got != want {
+		t.Errorf("sqlite:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(postgresDryRun(t), find), `SELECT user_id, COUNT(*) FROM "test_orders" GROUP BY "user_id"`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+
+	ordered := toSQL(mysqlDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).Group("user_id").Order("user_id").Scopes(NoSort()).Find(&[]map[string]any{})
+	})
+	if strings.Contains(ordered, "NULL") {
+		t.Errorf("existing order should be kept: %s", ordered)
+	}
+
+	later := toSQL(mysqlDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&testOrder{}).Group("user_id").Scopes(NoSort(), func(tx *gorm.DB) *gorm.DB { return tx.Order("user_id") }).Find(&[]map[string]any{})
+	})
+	if want := "SELECT * FROM `test_orders` GROUP BY `user_id` ORDER BY user_id"; later != want {
+		t.Errorf("later order should replace ORDER BY NULL:\n got  %s\n want %s", later, want)
+	}
+}
+
+func TestOrderBySafe(t *testing.T) {
+	db := sqliteDryRun(t)
+	allowed := []string{"id", "amount"}
+	tests := []struct {
+		orderBy string
+		want    string
+	}{
+		{"amount,-id", "SELECT * FROM `test_orders` ORDER BY `amount`,`id` DESC"},
+		{"-amount,note", "SELECT * FROM `test_orders` ORDER BY `amount` DESC"},
+		{"id; DROP TABLE test_orders", "SELECT * FROM `test_orders` ORDER BY `id` DESC"},
+		{"", "SELECT * FROM `test_orders` ORDER BY `id` DESC"},
+	}
+	for _, tt := range tests {
+		got := toSQL(db, func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(OrderBySafe(tt.orderBy, "-id", allowed...)).Find(&[]testOrder{})
+		})
+		if got != tt.want {
+			t.Errorf("OrderBySafe(%q)\ngot  %s\nwant %s", tt.orderBy, got, tt.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	type job struct {
+		ID        int
+		ElapsedMS int64
+	}
+	db := newTestDB(t, &job{})
+	db.Create(&[]job{{ID: 1, ElapsedMS: 500}, {ID: 2, ElapsedMS: 1500}, {ID: 3, ElapsedMS: 3000}})
+
+	tests := []struct {
+		scope Scope
+		want  []int
+	}{
+		{DurationGte("elapsed_ms", time.Second, time.Millisecond), []int{2, 3}},
+		{DurationLte("elapsed_ms", 1500*time.Millisecond, time.Millisecond), []int{1, 2}},
+		{DurationGte("elapsed_ms", 1500*time.Millisecond+time.Microsecond, time.Millisecond), []int{3}},
+		{DurationLte("elapsed_ms", 1500*time.Millisecond-time.Microsecond, time.Millisecond), []int{1}},
+		{DurationGte("elapsed_ms", time.Millisecond), []int{}},
+	}
+	for i, tt := range tests {
+		ids := []int{}
+		if err := db.Model(&job{}).Scopes(tt.scope).Order("id").Pluck("id", &ids).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%d: got %v, want %v", i, ids, tt.want)
+		}
+	}
+}
+
+func TestDeepPage(t *testing.T) {
+	old := deepPageOffset
+	deepPageOffset = 20
+	t.Cleanup(func() { deepPageOffset = old })
+
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 100)
+	odd := func(tx *gorm.DB) *gorm.DB { return tx.Where("id % 2 = 1") }
+
+	for _, page := range []int{1, 2, 3, 5, 7, 8} {
+		var deep, plain []ZZ
+		if err := db.Scopes(odd, DeepPage("id", page, 7)).Find(&deep).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Scopes(odd).Order("id").Scopes(Paging(page, 7, 0)).Find(&plain).Error; err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(deep) != fmt.Sprint(plain) {
+			t.Errorf("page %d:\n deep  %v\n plain %v", page, deep, plain)
+		}
+	}
+
+	sql := toSQL(sqliteDryRun(t), func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&ZZ{}).Where("sort > ?", 1).Scopes(DeepPage("id", 4, 10)).Find(&[]ZZ{})
+	})
+	want := "SELECT * FROM `zzs` WHERE sort > 1 AND `zzs`.`id` >= (SELECT `zzs`.`id` FROM `zzs` WHERE sort > 1 ORDER BY `zzs`.`id` LIMIT 1 OFFSET 30) ORDER BY `zzs`.`id` LIMIT 10"
+	if sql != want {
+		t.Errorf("got  %s\nwant %s", sql, want)
+	}
+}
+
+func TestIndexedBy(t *testing.T) {
+	find := func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(IndexedBy("idx_note")).Joins("JOIN test_users ON test_users.id = test_orders.user_id").Where("note = ?", "a").Find(&[]testOrder{})
+	}
+
+	if got, want := toSQL(sqliteDryRun(t), find), "SELECT `test_orders`.`id`,`test_orders`.`user_id`,`test_orders`.`amount`,`test_orders`.`note` FROM `test_orders` INDEXED BY `idx_note` JOIN test_users ON test_users.id = test_orders.user_id WHERE note = \"a\""; got != want {
+		t.Errorf("sqlite:\n got  %s\n want %s", got, want)
+	}
+	if got, want := toSQL(postgresDryRun(t), find), `SELECT "test_orders"."id","test_orders"."user_id","test_orders"."amount","test_orders"."note" FROM "test_orders" JOIN test_users ON test_users.id = test_orders.user_id WHERE note = 'a'`; got != want {
+		t.Errorf("postgres:\n got  %s\n want %s", got, want)
+	}
+
+	db := newTestDB(t, &testOrder{})
+	db.Exec("CREATE INDEX idx_note ON test_orders (note)")
+	db.Create(&testOrder{Note: "a"})
+	var orders []testOrder
+	if err := db.Scopes(IndexedBy("idx_note")).Where("note = ?", "a").Find(&orders).Error; err != nil || len(orders) != 1 {
+		t.Fatalf("orders = %+v, err = %v", orders, err)
+	}
+	if err := db.Scopes(IndexedBy("idx_missing")).Find(&orders).Error; err == nil {
+		t.Fatal("expected error for missing index")
+	}
+}
+
+func TestComment(t *testing.T) {
+	db := sqliteDryRun(t)
+	tests := []struct {
+		fn   func(tx *gorm.DB) *gorm.DB
+		want string
+	}{
+		{
+			func(tx *gorm.DB) *gorm.DB {
+				return tx.Scopes(Comment("service:api"), Comment("endpoint:list?")).Where("id = ?", 1).Find(&[]testOrder{})
+			},
+			"/* service:api, endpoint:list%3F */ SELECT * FROM `test_orders` WHERE id = 1",
+		},
+		{
+			func(tx *gorm.DB) *gorm.DB {
+				return tx.Scopes(Comment("x */ DROP TABLE users; /* y")).Where("id = ?", 1).Delete(&testOrder{})
+			},
+			"/* x * / DROP TABLE users; / * y */ DELETE FROM `test_orders` WHERE id = 1",
+		},
+		{
+			func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&testOrder{}).Scopes(Comment("job:sync")).Where("id = ?", 1).Update("note", "a")
+			},
+			"/* job:sync */ UPDATE `test_orders` SET `note`=\"a\" WHERE id = 1",
+		},
+		{
+			func(tx *gorm.DB) *gorm.DB {
+				return tx.Scopes(Comment("job:sync")).Create(&testOrder{Note: "a"})
+			},
+			"INSERT /* job:sync */ INTO `test_orders` (`user_id`,`amount`,`note`) VALUES (0,0,\"a\") RETURNING `id`",
+		},
+		{
+			func(tx *gorm.DB) *gorm.DB {
+				return tx.Clauses(clause.Insert{Modifier: "OR IGNORE"}).Scopes(Comment("a"), Comment("b")).Create(&testOrder{ID: 1})
+			},
+			"INSERT /* a, b */ OR IGNORE INTO `test_orders` (`user_id`,`amount`,`note`,`id`) VALUES (0,0,\"\",1) RETURNING `id`",
+		},
+	}
+	for _, tt := range tests {
+		if got := toSQL(db, tt.fn); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+
+	var count int64
+	live := newTestDB(t, &testOrder{})
+	if err := live.Model(&testOrder{}).Scopes(Comment("count")).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,55 +8,137 @@ import (
 
 const DEFAULT = "DEFAULT"
 
+// Single 是一个按名称缓存实例的容器，同一名称的并发获取只会触发一次创建。
+// 只有创建成功的实例才会被缓存，失败时下一次获取会重新尝试创建。
+type Single[T any] struct {
+	// get 是原始的实例创建函数。
+	get func(string) (T, error)
+	// ins 是一个缓存，用于存储通过名称创建的实例。
+	ins map[string]T
+	// sfg 用于确保相同的 name 只会有一个 goroutine 在执行 get 操作。
+	sfg singleflight.Group
+	// mu 保护 ins 的读写操作，以确保并发安全。
+	mu sync.RWMutex
+}
+
+// NewSingle 使用给定的创建函数构建一个 Single 实例。
+func NewSingle[T any](get func(string) (T, error)) *Single[T] {
+	return &Single[T]{get: get, ins: map[string]T{}}
+}
+
 // SingleWrap 是一个函数装饰器，用于缓存和去重处理。
 // 它接受一个函数 get，该函数通过名称获取一个类型为 T 的实例。
 // 返回一个新的函数，该函数会缓存 get 的调用结果，以避免重复获取相同的实例。
 func SingleWrap[T any](get func(string) (T, error)) func(string) (T, error) {
-	// ins 是一个缓存，用于存储通过名称创建的实例。
-	var (
-		ins = map[string]T{}
-		// sfg 用于确保相同的 name 只会有一个 goroutine 在执行 get 操作。
-		sfg singleflight.Group
-		// mu 保护 ins 的读写操作，以确保并发安全。
-		mu sync.RWMutex
-	)
-
-	// 返回一个新的函数，用于获取缓存或创建实例。
-	return func(name string) (out T, err error) {
-		// 如果 name 为空，则使用默认名称。
-		if name == "" {
-			name = DEFAULT
-		}
+	return NewSingle(get).Get
+}
+
+// Get 获取缓存中名称为 name 的实例，不存在时调用创建函数并缓存结果。
+// 如果 name 为空，则使用默认名称 DEFAULT。
+func (s *Single[T]) Get(name string) (out T, err error) {
+	// 如果 name 为空，则使用默认名称。
+	if name == "" {
+		name = DEFAULT
+	}
+
+	// 尝试从缓存中读取实例。
+	s.mu.RLock()
+	if instance, ok := s.ins[name]; ok {
+		s.mu.RUnlock()
+		// 如果找到实例，直接返回。
+		return instance, nil
+	}
+	s.mu.RUnlock()
 
-		// 尝试从缓存中读取实例。
-		mu.RLock()
-		if instance, ok := ins[name]; ok {
-			mu.RUnlock()
-			// 如果找到实例，直接返回。
-			return instance, nil
+	// 使用 singleflight 机制，避免相同的 name 被同时多次调用。
+	instance, err, _ := s.sfg.Do(name, func() (any, error) {
+		// 调用原始的 get 函数获取实例。
+		v, err := s.get(name)
+		if err != nil {
+			return nil, err
 		}
-		mu.RUnlock()
-
-		// 使用 singleflight 机制，避免相同的 name 被同时多次调用。
-		instance, err, _ := sfg.Do(name, func() (any, error) {
-			// 调用原始的 get 函数获取实例。
-			v, err := get(name)
-			if err != nil {
-				return nil, err
-			}
-			// 将获取的实例存储到缓存中。
-			mu.Lock()
-			ins[name] = v
-			mu.Unlock()
-			return v, nil
-		})
-
-		// 如果有错误发生，返回错误。
+		// 将获取的实例存储到缓存中。
+		s.mu.Lock()
+		s.ins[name] = v
+		s.mu.Unlock()
+		return v, nil
+	})
+
+	// 如果有错误发生，返回错误。
+	if err != nil {
+		return out, err
+	}
+
+	// 将结果转换为类型 T 并返回。
+	return instance.(T), nil
+}
+
+// Snapshot 返回当前缓存的只读快照。
+// 返回的 map 是一份拷贝，对它的修改不会影响缓存本身。
+func (s *Single[T]) Snapshot() map[string]T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]T, len(s.ins))
+	for name, v := range s.ins {
+		out[name] = v
+	}
+	return out
+}
+
+// Renew 重新调用创建函数生成名称为 name 的实例并替换缓存中的旧实例，返回新实例。
+// 如果 name 为空，则使用默认名称 DEFAULT。
+// 创建失败时保留旧实例并返回错误；替换成功且存在旧实例时以旧实例调用 release（可以为 nil），
+// 同一名称的并发 Renew 只会创建一次、释放一次。
+func (s *Single[T]) Renew(name string, release func(T)) (out T, err error) {
+	if name == "" {
+		name = DEFAULT
+	}
+
+	instance, err, _ := s.sfg.Do(name, func() (any, error) {
+		v, err := s.get(name)
 		if err != nil {
-			return out, err
+			return nil, err
 		}
 
-		// 将结果转换为类型 T 并返回。
-		return instance.(T), nil
+		s.mu.Lock()
+		old, ok := s.ins[name]
+		s.ins[name] = v
+		s.mu.Unlock()
+
+		if ok && release != nil {
+			release(old)
+		}
+		return v, nil
+	})
+	if err != nil {
+		return out, err
 	}
+	return instance.(T), nil
+}
+
+// Evict 从缓存中移除名称为 name 的实例并返回它，name 为空时使用默认名称 DEFAULT。
+// 实例不存在时 ok 为 false。正在进行中的创建不受影响，完成后仍会写入缓存。
+func (s *Single[T]) Evict(name string) (out T, ok bool) {
+	if name == "" {
+		name = DEFAULT
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if out, ok = s.ins[name]; ok {
+		delete(s.ins, name)
+	}
+	return
+}
+
+// EvictAll 清空缓存，返回被移除的全部实例。
+func (s *Single[T]) EvictAll() map[string]T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := s.ins
+	s.ins = map[string]T{}
+	return out
 }
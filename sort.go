@@ -3,14 +3,42 @@ package gormx
 import (
 	"bytes"
 	"cmp"
+	"fmt"
+	"reflect"
 	"slices"
+	"sync/atomic"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// sortJoinThreshold 是 SortExec 改用临时表关联更新的阈值，0 表示不启用。
+var sortJoinThreshold atomic.Int64
+
+// sortTempSeq 用于生成唯一的临时表名。
+var sortTempSeq atomic.Int64
+
+// sortJoinBatch 是写入临时表时每条 INSERT 语句包含的行数。
+// 纯 Go 版本的 sqlite 驱动无法预编译包含数百行 VALUES 的语句，因此不宜过大。
+const sortJoinBatch = 200
+
+// SetSortJoinThreshold 设置 SortExec 使用临时表关联更新的阈值。
+//
+// 当待排序的记录数超过 n 且方言支持 UPDATE ... FROM（目前为 postgres 和 sqlite）时，
+// SortExec 会在事务中创建一个 (k, s) 临时表，批量写入键值对后通过关联更新排序列，
+// 以代替体积巨大的 CASE 表达式；不满足条件时仍使用 CASE 方式。
+// n 小于等于 0 表示关闭该策略，这也是默认行为。
+func SetSortJoinThreshold(n int) { sortJoinThreshold.Store(int64(n)) }
+
 // SortOptions 定义了排序选项的结构体。
-// 主要用于指定对数据库表或模型进行操作所需的排序相关信息。
+// 主要用于指定对数据库表或模型进行操作所需的排序相关信息，配合 SortWith 使用。
+//
+// Table 的解析规则:
+//
+//	string          - 直接作为表名，此时 KeyColumn 默认为 "id"。
+//	结构体或其指针  - 作为模型，表名由 gorm 解析（支持 Tabler 接口），KeyColumn 默认为模型主键。
+//	nil             - 使用 tx 上已经设置的 Model 或 Table。
 type SortOptions struct {
 	Table      any    // 表名或模型。可以是表名或模型结构体。
 	KeyColumn  string // 键列名，用于标识需要排序的记录，默认为主键。
@@ -19,7 +47,7 @@ type SortOptions struct {
 
 // SortPrep 为排序前的准备操作生成 SQL 表达式。
 //
-// 该函数根据提供的映射，创建一个 CASE 表达式来指定排序的值，并生成 WHERE 表达式来过滤结果。
+// 该函数根据提供的映射，通过 CaseExpr 相同的方式创建一个 CASE 表达式来指定排序的值，并生成 WHERE 表达式来过滤结果。
 //
 // 参数:
 //
@@ -32,38 +60,11 @@ type SortOptions struct {
 //	where - 一个 clause.Expr，用于在查询中使用，以过滤出需要排序的记录。
 //	value - 一个 clause.Expr，表示 CASE 表达式，用于指定排序的值。
 func SortPrep[K cmp.Ordered, S cmp.Ordered](values map[K]S, kc, sc clause.Column) (where clause.Expr, value clause.Expr) {
-	// 获取映射中键的数量，用于初始化键切片的容量。
-	l := len(values)
-	// 创建一个切片来存储映射的所有键。
-	keys := make([]K, 0, l)
-	// 遍历映射，将键添加到切片中。
-	for key := range values {
-		keys = append(keys, key)
-	}
-	// 对键切片进行排序，以确保 CASE 表达式的顺序。
-	slices.Sort(keys)
-
-	// 创建一个缓冲区来构建 CASE 表达式的 SQL 字符串。
-	caseSql := bytes.NewBufferString(`(CASE ?`)
-	// 创建一个切片来存储 CASE 表达式的所有参数。
-	caseArg := make([]any, 0, l*2+2)
-	// 将排序键列添加到参数中。
-	caseArg = append(caseArg, kc)
-
-	// 遍历排序后的键，构建 CASE 表达式的 WHEN THEN 部分。
-	for _, key := range keys {
-		caseSql.WriteString(` WHEN ? THEN ?`)
-		caseArg = append(caseArg, key, values[key])
-	}
-	// 添加 CASE 表达式的 ELSE 部分和结束括号。
-	caseSql.WriteString(` ELSE ? END)`)
-	// 将默认排序列添加到参数中。
-	caseArg = append(caseArg, sc)
+	// 构建 CASE 表达式，未匹配的记录保持原排序值。
+	value, keys := caseExpr(kc, values, sc)
 
 	// 构建 WHERE 表达式，用于过滤出需要排序的记录。
 	where = gorm.Expr(`? in (?)`, kc, keys)
-	// 构建最终的 CASE 表达式 clause.Expr。
-	value = gorm.Expr(caseSql.String(), caseArg...)
 	// 返回 WHERE 和 VALUE 表达式。
 	return
 }
@@ -78,6 +79,9 @@ func SortPrep[K cmp.Ordered, S cmp.Ordered](values map[K]S, kc, sc clause.Column
 //	values - 是一个映射，其键和值分别对应数据库记录中的键和排序值。
 //	keyColumn 和 sortColumn - 是数据库表中的列名，分别用于标识键列和排序列。
 //
+// 更新会使用 tx 上绑定的上下文，调用方应通过 tx.WithContext(ctx) 传入上下文，
+// 以便在上下文取消或超时时中止更新；上下文已经取消时不会发起任何 SQL。
+//
 // 函数返回更新操作后的 GORM DB 对象。
 func SortExec[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S, keyColumn, sortColumn string) *gorm.DB {
 	// 初始化键列和排序列的 Clause 对象。
@@ -89,6 +93,13 @@ func SortExec[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S, keyColu
 		tx = Default()
 	}
 
+	// 上下文已经取消时直接返回错误，不再发起更新。
+	if ctx := tx.Statement.Context; ctx != nil && ctx.Err() != nil {
+		tx = tx.Session(&gorm.Session{})
+		_ = tx.AddError(ctx.Err())
+		return tx
+	}
+
 	// 如果键列名为空，尝试从 Model 中获取主键名，如果 Model 为空，则默认为 "id"。
 	if kc.Name == "" {
 		if tx.Statement.Model != nil {
@@ -103,6 +114,11 @@ func SortExec[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S, keyColu
 		sc.Name = "sort"
 	}
 
+	// 记录数超过阈值且方言支持时，使用临时表关联更新。
+	if n := sortJoinThreshold.Load(); n > 0 && int64(len(values)) > n && Supports(tx, FeatureUpdateFrom) {
+		return sortJoin(tx, values, kc, sc)
+	}
+
 	// 调用 SortPrep 函数准备 WHERE 子句和更新值。
 	where, value := SortPrep(values, kc, sc)
 
@@ -110,12 +126,44 @@ func SortExec[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S, keyColu
 	return tx.Where(where).UpdateColumn(sc.Name, value)
 }
 
+// SortWith 与 SortExec 相同，但通过 SortOptions 指定目标表、键列和排序列。
+// Table 的解析规则见 SortOptions，Table 为不支持的类型时返回带有错误的 DB。
+func SortWith[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S, opts SortOptions) *gorm.DB {
+	if tx == nil {
+		tx = Default()
+	}
+
+	switch table := opts.Table.(type) {
+	case nil:
+	case string:
+		tx = tx.Table(table)
+	default:
+		rv := reflect.ValueOf(table)
+		switch {
+		case rv.Kind() == reflect.Struct:
+			// 非指针的结构体复制到一个可寻址的新值上，以便 gorm 解析。
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			tx = tx.Model(ptr.Interface())
+		case rv.Kind() == reflect.Pointer && rv.Type().Elem().Kind() == reflect.Struct:
+			tx = tx.Model(table)
+		default:
+			tx = tx.Session(&gorm.Session{})
+			_ = tx.AddError(fmt.Errorf("sort: unsupported table type %T", table))
+			return tx
+		}
+	}
+
+	return SortExec(tx, values, opts.KeyColumn, opts.SortColumn)
+}
+
 // Sort 函数用于更新数据库中的排序信息。
 //
 // 该函数接收一个 *gorm.DB 类型的参数 tx，代表数据库事务，
 // 以及一个映射类型 values，其键和值都实现了 Ordered 接口，
 // 用于指定需要更新排序信息的实体及其新的排序值。
 // 函数返回更新的行数和遇到的错误（如果有）。
+// 与 SortExec 一样，可以通过 tx.WithContext(ctx) 控制取消和超时。
 func Sort[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S) (rowsUpdated int64, err error) {
 	// 调用 SortExec 函数执行排序更新操作，传入空字符串作为排序字段和表名，
 	// 这意味着 SortExec 需要根据上下文自行决定如何执行排序更新。
@@ -123,3 +171,93 @@ func Sort[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S) (rowsUpdate
 	// 返回更新的行数和遇到的错误。
 	return tx.RowsAffected, tx.Error
 }
+
+// sortJoin 通过临时表关联的方式批量更新排序列。
+// 临时表的列类型通过 CREATE TEMPORARY TABLE ... AS SELECT 从目标表复制，
+// 最终的 UPDATE ... FROM 语句保留 tx 上的查询条件和软删除过滤，
+// 整个过程在一个事务中完成，以保证临时表与更新语句使用同一个连接。
+func sortJoin[K cmp.Ordered, S cmp.Ordered](tx *gorm.DB, values map[K]S, kc, sc clause.Column) *gorm.DB {
+	tx = tx.Session(&gorm.Session{})
+
+	// 解析目标表名和键列名。
+	table := tx.Statement.Table
+	if tx.Statement.Model != nil {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(tx.Statement.Model); err != nil {
+			_ = tx.AddError(err)
+			return tx
+		}
+		if table == "" {
+			table = stmt.Schema.Table
+		}
+		if kc.Name == clause.PrimaryKey {
+			if stmt.Schema.PrioritizedPrimaryField == nil {
+				_ = tx.AddError(gorm.ErrPrimaryKeyRequired)
+				return tx
+			}
+			kc.Name = stmt.Schema.PrioritizedPrimaryField.DBName
+		}
+	}
+	if table == "" {
+		_ = tx.AddError(fmt.Errorf("sort: table not set"))
+		return tx
+	}
+
+	keys := make([]K, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var (
+		target = clause.Table{Name: table}
+		temp   = clause.Table{Name: fmt.Sprintf("gormx_sort_%d_%d", time.Now().UnixNano(), sortTempSeq.Add(1))}
+		key    = clause.Column{Table: table, Name: kc.Name}
+		sort   = clause.Column{Table: table, Name: sc.Name}
+		result *gorm.DB
+	)
+
+	err := tx.Transaction(func(db *gorm.DB) (err error) {
+		// 创建与目标表键列、排序列类型一致的临时表。
+		if err = db.Exec("CREATE TEMPORARY TABLE ? AS SELECT ? AS k, ? AS s FROM ? WHERE 1 = 0", temp, key, sort, target).Error; err != nil {
+			return err
+		}
+		// 删除临时表失败时回滚事务，避免临时表残留在连接上；更新已经失败时保留原来的错误。
+		defer func() {
+			if dropErr := db.Exec("DROP TABLE ?", temp).Error; err == nil && dropErr != nil {
+				err = fmt.Errorf("sort: drop temp table: %w", dropErr)
+			}
+		}()
+
+		// 分批写入键值对。
+		for start := 0; start < len(keys); start += sortJoinBatch {
+			batch := keys[start:min(start+sortJoinBatch, len(keys))]
+			sql := bytes.NewBufferString("INSERT INTO ? (k, s) VALUES ")
+			args := make([]any, 0, len(batch)*2+1)
+			args = append(args, temp)
+			for i, k := range batch {
+				if i > 0 {
+					sql.WriteString(", ")
+				}
+				sql.WriteString("(?, ?)")
+				args = append(args, k, values[k])
+			}
+			if err := db.Exec(sql.String(), args...).Error; err != nil {
+				return err
+			}
+		}
+
+		// 关联临时表更新排序列，通过 gorm 构建语句以保留 tx 上的查询条件和软删除过滤，
+		// 与 CASE 方式更新的记录范围一致。
+		result = db.Clauses(clause.From{Tables: []clause.Table{temp}}).
+			Where("? = ?", key, clause.Column{Table: temp.Name, Name: "k"}).
+			UpdateColumn(sc.Name, clause.Column{Table: temp.Name, Name: "s"})
+		return result.Error
+	})
+
+	if err != nil {
+		_ = tx.AddError(err)
+		return tx
+	}
+	return result
+}
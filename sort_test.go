@@ -1,7 +1,10 @@
 package gormx
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"slices"
 	"testing"
 	"unsafe"
 
@@ -45,3 +48,185 @@ type ZZ struct {
 	Sort      int
 	UpdatedAt int64
 }
+
+// seedSort 写入 n 条记录并返回一个反转排序值的映射。
+func seedSort(t testing.TB, db *gorm.DB, n int) map[int]int {
+	t.Helper()
+	rows := make([]ZZ, n)
+	sorts := make(map[int]int, n)
+	for i := range rows {
+		rows[i] = ZZ{ID: i + 1, Sort: i + 1}
+		sorts[i+1] = n - i
+	}
+	if err := db.CreateInBatches(rows, 200).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return sorts
+}
+
+func setSortJoinThreshold(t testing.TB, n int) {
+	old := int(sortJoinThreshold.Load())
+	SetSortJoinThreshold(n)
+	t.Cleanup(func() { SetSortJoinThreshold(old) })
+}
+
+// skipLargeCaseSort 为 true 时 TestSortJoin 跳过大批量的 CASE 更新，
+// 纯 Go 版本的 sqlite 无法处理过长的 CASE 表达式。
+var skipLargeCaseSort bool
+
+func TestSortJoin(t *testing.T) {
+	const n = 1200
+
+	// 只比较键和排序值，UpdatedAt 由写入时间决定，两次写入可能跨越秒边界。
+	results := map[string][][2]int{}
+	for name, threshold := range map[string]int{"case": 0, "join": 100} {
+		if name == "case" && skipLargeCaseSort {
+			continue
+		}
+		setSortJoinThreshold(t, threshold)
+
+		db := newTestDB(t, &ZZ{})
+		sorts := seedSort(t, db, n)
+		sorts[n+1] = 1 // 不存在的记录不应被更新
+
+		rows, err := Sort(db.Model(&ZZ{}), sorts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if rows != n {
+			t.Fatalf("%s: rows updated = %d, want %d", name, rows, n)
+		}
+
+		var got []ZZ
+		db.Order("id").Find(&got)
+		for _, r := range got {
+			results[name] = append(results[name], [2]int{r.ID, r.Sort})
+		}
+	}
+
+	if cases, ok := results["case"]; ok && !slices.Equal(cases, results["join"]) {
+		t.Fatal("case and join strategies produced different results")
+	}
+	if len(results["join"]) != n {
+		t.Fatalf("join: %d rows, want %d", len(results["join"]), n)
+	}
+	for _, r := range results["join"] {
+		if r[1] != n+1-r[0] {
+			t.Fatalf("row %d: sort = %d, want %d", r[0], r[1], n+1-r[0])
+		}
+	}
+}
+
+type sortScoped struct {
+	ID        int
+	Sort      int
+	Grp       int
+	DeletedAt gorm.DeletedAt
+}
+
+func TestSortJoinConditions(t *testing.T) {
+	for name, threshold := range map[string]int{"case": 0, "join": 1} {
+		setSortJoinThreshold(t, threshold)
+
+		db := newTestDB(t, &sortScoped{})
+		rows := []sortScoped{{ID: 1, Grp: 1}, {ID: 2, Grp: 1}, {ID: 3, Grp: 2}, {ID: 4, Grp: 1}}
+		db.Create(&rows)
+		db.Delete(&sortScoped{}, 4)
+
+		n, err := Sort(db.Model(&sortScoped{}).Where("grp = ?", 1), map[int]int{1: 10, 2: 20, 3: 30, 4: 40})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if n != 2 {
+			t.Fatalf("%s: rows updated = %d, want 2", name, n)
+		}
+
+		var got []sortScoped
+		db.Unscoped().Order("id").Find(&got)
+		for i, want := range []int{10, 20, 0, 0} {
+			if got[i].Sort != want {
+				t.Fatalf("%s: row %d sort = %d, want %d", name, got[i].ID, got[i].Sort, want)
+			}
+		}
+	}
+}
+
+func BenchmarkSortExec(b *testing.B) {
+	for name, threshold := range map[string]int{"case": 0, "join": 100} {
+		b.Run(name, func(b *testing.B) {
+			setSortJoinThreshold(b, threshold)
+			db := newTestDB(b, &ZZ{})
+			sorts := seedSort(b, db, 2000)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := SortExec(db.Model(&ZZ{}), sorts, "", "").Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSortCanceled(t *testing.T) {
+	for name, threshold := range map[string]int{"case": 0, "join": 1} {
+		setSortJoinThreshold(t, threshold)
+
+		db := newTestDB(t, &ZZ{})
+		sorts := seedSort(t, db, 10)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		rows, err := Sort(db.WithContext(ctx).Model(&ZZ{}), sorts)
+		if !errors.Is(err, context.Canceled) || rows != 0 {
+			t.Fatalf("%s: rows = %d, err = %v, want context.Canceled", name, rows, err)
+		}
+
+		var changed int64
+		db.Model(&ZZ{}).Where("sort <> id").Count(&changed)
+		if changed != 0 {
+			t.Fatalf("%s: %d rows updated after cancel", name, changed)
+		}
+	}
+}
+
+type zzTabler struct {
+	ID   int
+	Rank int `gorm:"column:sort"`
+}
+
+func (zzTabler) TableName() string { return "zzs" }
+
+func TestSortWith(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+	seedSort(t, db, 3)
+
+	tests := []struct {
+		name string
+		tx   *gorm.DB
+		opts SortOptions
+	}{
+		{"string", db, SortOptions{Table: "zzs"}},
+		{"struct", db, SortOptions{Table: zzTabler{}, SortColumn: "sort"}},
+		{"pointer", db, SortOptions{Table: &ZZ{}}},
+		{"nil", db.Model(&ZZ{}), SortOptions{}},
+	}
+
+	for i, tt := range tests {
+		values := map[int]int{1: 10 * (i + 1), 3: 30 * (i + 1)}
+		result := SortWith(tt.tx, values, tt.opts)
+		if result.Error != nil || result.RowsAffected != 2 {
+			t.Fatalf("%s: rows = %d, err = %v", tt.name, result.RowsAffected, result.Error)
+		}
+
+		var rows []ZZ
+		db.Order("id").Find(&rows)
+		if rows[0].Sort != values[1] || rows[1].Sort != 2 || rows[2].Sort != values[3] {
+			t.Fatalf("%s: unexpected rows %+v", tt.name, rows)
+		}
+	}
+
+	if err := SortWith(db, map[int]int{1: 1}, SortOptions{Table: 1}).Error; err == nil {
+		t.Fatal("expected error for unsupported table type")
+	}
+}
@@ -0,0 +1,81 @@
+package gormx
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// TxOption 是 Transaction 的事务选项。
+type TxOption func(*sql.TxOptions)
+
+// WithIsolation 设置事务的隔离级别，例如 sql.LevelRepeatableRead、sql.LevelSerializable。
+// Transaction 会在开启事务前校验方言是否支持该隔离级别，sqlite 不支持设置隔离级别（见 isolationLevels）。
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *sql.TxOptions) { o.Isolation = level }
+}
+
+// isolationLevels 记录各方言支持的隔离级别，未列出的方言不做校验。
+// sql.LevelDefault 总是允许的，表示使用数据库的默认隔离级别。
+// sqlite 的事务本身就是串行化的，驱动会忽略 sql.TxOptions 中的隔离级别，因此不接受任何显式的隔离级别。
+var isolationLevels = map[string][]sql.IsolationLevel{
+	"sqlite":    {},
+	"mysql":     {sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable},
+	"postgres":  {sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable},
+	"sqlserver": {sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSnapshot, sql.LevelSerializable},
+}
+
+// Transaction 在事务中执行 fn，fn 返回错误或 panic 时回滚，否则提交。
+// 与 db.Transaction 相同，但可以通过 opts 设置事务选项，例如 WithIsolation。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	fn - 在事务中执行的函数，tx 为事务连接。
+//	opts - 事务选项。
+//
+// 返回值:
+//
+//	error - 隔离级别不受方言支持、开启事务失败或 fn 返回的错误。
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error, opts ...TxOption) error {
+	if len(opts) == 0 {
+		return db.Transaction(fn)
+	}
+
+	var txOpts sql.TxOptions
+	for _, opt := range opts {
+		opt(&txOpts)
+	}
+
+	if level := txOpts.Isolation; level != sql.LevelDefault {
+		name := dialectName(db)
+		if levels, ok := isolationLevels[name]; ok && !slices.Contains(levels, level) {
+			return fmt.Errorf("isolation level %s is not supported by %s", level, name)
+		}
+	}
+
+	return db.Transaction(fn, &txOpts)
+}
+
+// TransactionValue 与 Transaction 相同，但 fn 可以返回一个值，例如事务中创建的记录。
+// fn 返回 nil 错误时提交事务并返回该值；返回错误、panic 或提交失败时回滚，并返回 T 的零值和错误。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	fn - 在事务中执行的函数。
+//	opts - 事务选项，同 Transaction。
+func TransactionValue[T any](db *gorm.DB, fn func(tx *gorm.DB) (T, error), opts ...TxOption) (T, error) {
+	var out T
+	err := Transaction(db, func(tx *gorm.DB) (err error) {
+		out, err = fn(tx)
+		return err
+	}, opts...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
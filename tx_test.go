@@ -0,0 +1,85 @@
+package gormx
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestTransactionIsolation(t *testing.T) {
+	db := newTestDB(t, &ZZ{})
+
+	err := Transaction(db, func(tx *gorm.DB) error {
+		return tx.Create(&ZZ{ID: 1}).Error
+	}, WithIsolation(sql.LevelDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	db.Model(&ZZ{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("count = %d, want 1", count)
+	}
+
+	// sqlite 驱动忽略隔离级别，任何显式的隔离级别都应报错，而不是被静默忽略
+	for _, level := range []sql.IsolationLevel{sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelSerializable} {
+		err = Transaction(db, func(tx *gorm.DB) error { return nil }, WithIsolation(level))
+		if want := "isolation level " + level.String() + " is not supported by sqlite"; err == nil || err.Error() != want {
+			t.Fatalf("%s: err = %v, want %s", level, err, want)
+		}
+	}
+}
+
+func TestTransactionIsolationPostgres(t *testing.T) {
+	db := postgresTestDB(t, &ZZ{})
+
+	err := Transaction(db, func(tx *gorm.DB) error {
+		var level string
+		if err := tx.Raw("SHOW transaction_isolation").Scan(&level).Error; err != nil {
+			return err
+		}
+		if level != "serializable" {
+			t.Errorf("isolation = %q, want serializable", level)
+		}
+		return nil
+	}, WithIsolation(sql.LevelSerializable))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionValue(t *testing.T) {
+	db := newTestDB(t, &testOrder{})
+
+	id, err := TransactionValue(db, func(tx *gorm.DB) (int, error) {
+		order := testOrder{UserID: 1, Note: "created"}
+		err := tx.Create(&order).Error
+		return order.ID, err
+	})
+	if err != nil || id == 0 {
+		t.Fatalf("id = %d, err = %v", id, err)
+	}
+	var order testOrder
+	if err = db.First(&order, id).Error; err != nil || order.Note != "created" {
+		t.Fatalf("not committed: %+v, err = %v", order, err)
+	}
+
+	id, err = TransactionValue(db, func(tx *gorm.DB) (int, error) {
+		order := testOrder{UserID: 2}
+		if err := tx.Create(&order).Error; err != nil {
+			return 0, err
+		}
+		return order.ID, errors.New("boom")
+	})
+	if err == nil || id != 0 {
+		t.Fatalf("id = %d, err = %v, want zero value and error", id, err)
+	}
+	var count int64
+	db.Model(&testOrder{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("count = %d, want 1 after rollback", count)
+	}
+}
@@ -0,0 +1,93 @@
+package gormx
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveAll 逐条插入或更新记录，与 gorm 的 Save 不同，冲突时只更新记录中的非零值字段，
+// 零值字段保持数据库中的原值，避免被意外覆盖。
+//
+// 每条记录执行一次 INSERT ... ON CONFLICT（mysql 为 ON DUPLICATE KEY UPDATE），
+// 全部记录在一个事务中完成，任意一条失败时整体回滚。
+// 自动更新时间字段（如 UpdatedAt）总会被更新，自动创建时间字段（如 CreatedAt）不会被更新。
+// 记录的全部字段都是零值时，冲突时不做任何修改。
+//
+// 参数:
+//
+//	db - 数据库连接。
+//	records - 需要保存的记录，插入后生成的主键等会回填到记录中。
+//	conflictColumns - 判断冲突的列（唯一索引或主键），为空时使用模型的主键。
+//
+// 返回值:
+//
+//	int64 - 受影响的行数之和。
+//	error - 解析模型或执行失败时返回错误。
+func SaveAll[T any](db *gorm.DB, records []T, conflictColumns ...string) (int64, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return 0, err
+	}
+	s := stmt.Schema
+
+	var conflict []clause.Column
+	skip := map[string]bool{}
+	for _, c := range conflictColumns {
+		name := column(c).Name
+		conflict = append(conflict, clause.Column{Name: name})
+		skip[name] = true
+	}
+	if len(conflict) == 0 {
+		if len(s.PrimaryFields) == 0 {
+			return 0, gorm.ErrPrimaryKeyRequired
+		}
+		for _, f := range s.PrimaryFields {
+			conflict = append(conflict, clause.Column{Name: f.DBName})
+		}
+	}
+
+	var rows int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ctx := tx.Statement.Context
+		for i := range records {
+			rv := reflect.Indirect(reflect.ValueOf(&records[i]))
+			for rv.Kind() == reflect.Pointer {
+				rv = rv.Elem()
+			}
+
+			var columns []string
+			for _, f := range s.Fields {
+				if f.DBName == "" || f.PrimaryKey || skip[f.DBName] || !f.Updatable || f.AutoCreateTime > 0 {
+					continue
+				}
+				if _, zero := f.ValueOf(ctx, rv); !zero || f.AutoUpdateTime > 0 {
+					columns = append(columns, f.DBName)
+				}
+			}
+
+			onConflict := clause.OnConflict{Columns: conflict}
+			if len(columns) == 0 {
+				onConflict.DoNothing = true
+			} else {
+				onConflict.DoUpdates = clause.AssignmentColumns(columns)
+			}
+
+			result := tx.Clauses(onConflict).Create(&records[i])
+			if result.Error != nil {
+				return result.Error
+			}
+			rows += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
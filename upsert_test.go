@@ -0,0 +1,39 @@
+package gormx
+
+import "testing"
+
+func TestSaveAll(t *testing.T) {
+	db := newTestDB(t, &testOrder{})
+
+	orders := []testOrder{{ID: 1, UserID: 1, Amount: 10, Note: "first"}, {ID: 2, UserID: 2, Amount: 20, Note: "second"}}
+	if n, err := SaveAll(db, orders); err != nil || n != 2 {
+		t.Fatalf("insert: n = %d, err = %v", n, err)
+	}
+
+	// 只修改 Amount，其他字段为零值，不应覆盖已有数据。
+	if n, err := SaveAll(db, []testOrder{{ID: 1, Amount: 99}, {ID: 3, UserID: 3, Note: "third"}}); err != nil || n != 2 {
+		t.Fatalf("upsert: n = %d, err = %v", n, err)
+	}
+
+	var got []testOrder
+	if err := db.Order("id").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []testOrder{
+		{ID: 1, UserID: 1, Amount: 99, Note: "first"},
+		{ID: 2, UserID: 2, Amount: 20, Note: "second"},
+		{ID: 3, UserID: 3, Note: "third"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if n, err := SaveAll(db, []testOrder{}); err != nil || n != 0 {
+		t.Fatalf("empty: n = %d, err = %v", n, err)
+	}
+}